@@ -0,0 +1,327 @@
+package m3lshttp
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mohamed-essam/m3lsh"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type binder struct {
+	errors          []FieldError
+	unknown         []FieldError
+	disallowUnknown bool
+	// pathKeys are top level keys that came from path params, they are never unknown
+	pathKeys map[string]bool
+}
+
+func (p ParamsWrapper) Bind(dst interface{}) {
+	p.bindObject(p.object, dst, nil)
+}
+
+// bindInput binds a typed handler's input from the body merged with the path params,
+// path params win like in ToMap; a body that is not an object is bound on its own
+func (p *ParamsWrapper) bindInput(dst interface{}) {
+	mp, _ := p.object.(map[string]interface{})
+	body := mp[dataKey]
+	if body == "" {
+		body = nil
+	}
+	if !p.root || !mergeableBody(body) {
+		p.bindObject(body, dst, nil)
+		return
+	}
+	merged := make(map[string]interface{}, len(mp))
+	if fields, ok := body.(map[string]interface{}); ok {
+		for k, v := range fields {
+			merged[k] = v
+		}
+	}
+	pathKeys := make(map[string]bool)
+	for k, v := range mp {
+		if k != dataKey {
+			merged[k] = v
+			pathKeys[k] = true
+		}
+	}
+	if body == nil && len(pathKeys) == 0 {
+		p.bindObject(nil, dst, nil)
+		return
+	}
+	p.bindObject(merged, dst, pathKeys)
+}
+
+func (p ParamsWrapper) bindObject(object interface{}, dst interface{}, pathKeys map[string]bool) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		m3lsh.Throw(&InvalidTypeException{Object: dst}, "Bind destination must be a non-nil pointer")
+	}
+	b := &binder{errors: make([]FieldError, 0), disallowUnknown: p.options != nil && p.options.DisallowUnknownFields, pathKeys: pathKeys}
+	b.bind(object, v.Elem(), "")
+	if len(b.unknown) > 0 {
+		m3lsh.Throw(&BadRequest{httpException{Problem: Problem{Fields: map[string]interface{}{"errors": b.unknown}}}}, "Parameters contain unknown fields")
+	}
+	errors := b.errors
+	for _, e := range validateValue(v, "", make([]FieldError, 0)) {
+		if !hasFieldError(b.errors, e.Field) {
+			errors = append(errors, e)
+		}
+	}
+	if len(errors) > 0 {
+		throwFieldErrors("Parameters are invalid", errors)
+	}
+}
+
+func hasFieldError(errors []FieldError, field string) bool {
+	for _, e := range errors {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func throwFieldErrors(message string, errors []FieldError) {
+	m3lsh.Throw(&UnprocessableEntity{httpException{Problem: Problem{Fields: map[string]interface{}{"errors": errors}}}}, message)
+}
+
+func (b *binder) fail(path, message string) {
+	b.errors = append(b.errors, FieldError{Field: path, Message: message})
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	uuidType     = reflect.TypeOf(UUID{})
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+func (b *binder) bind(src interface{}, dst reflect.Value, path string) {
+	if src == nil {
+		return
+	}
+	params := ParamsWrapper{object: src}
+	switch dst.Type() {
+	case timeType:
+		b.convert(path, func() { dst.Set(reflect.ValueOf(params.Time())) })
+		return
+	case durationType:
+		b.convert(path, func() { dst.SetInt(int64(params.Duration())) })
+		return
+	case uuidType:
+		b.convert(path, func() { dst.Set(reflect.ValueOf(params.UUID())) })
+		return
+	case bytesType:
+		b.convert(path, func() { dst.SetBytes(params.Bytes()) })
+		return
+	}
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		b.bind(src, dst.Elem(), path)
+	case reflect.Interface:
+		if dst.NumMethod() > 0 {
+			b.fail(path, fmt.Sprintf("cannot bind into %s", dst.Type()))
+			return
+		}
+		dst.Set(reflect.ValueOf(src))
+	case reflect.Struct:
+		b.bindStruct(src, dst, path)
+	case reflect.Map:
+		b.bindMap(src, dst, path)
+	case reflect.Slice:
+		b.bindSlice(src, dst, path)
+	case reflect.Array:
+		b.bindArray(src, dst, path)
+	case reflect.String:
+		str, ok := src.(string)
+		if !ok {
+			b.fail(path, "Object is not a string")
+			return
+		}
+		dst.SetString(str)
+	case reflect.Bool:
+		b.convert(path, func() { dst.SetBool(params.AsBool()) })
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.convert(path, func() {
+			num := params.AsLong()
+			if dst.OverflowInt(num) {
+				b.fail(path, fmt.Sprintf("%d overflows %s", num, dst.Type()))
+				return
+			}
+			dst.SetInt(num)
+		})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b.convert(path, func() {
+			num := params.AsLong()
+			if num < 0 || dst.OverflowUint(uint64(num)) {
+				b.fail(path, fmt.Sprintf("%d overflows %s", num, dst.Type()))
+				return
+			}
+			dst.SetUint(uint64(num))
+		})
+	case reflect.Float32, reflect.Float64:
+		b.convert(path, func() {
+			num := params.AsDouble()
+			if dst.OverflowFloat(num) {
+				b.fail(path, fmt.Sprintf("%v overflows %s", num, dst.Type()))
+				return
+			}
+			dst.SetFloat(num)
+		})
+	default:
+		b.fail(path, fmt.Sprintf("cannot bind into %s", dst.Type()))
+	}
+}
+
+// convert runs one of the As* accessors and records its InvalidTypeException as a field error
+func (b *binder) convert(path string, fn func()) {
+	ex := m3lsh.Try(fn)
+	if ex == nil {
+		return
+	}
+	invalid, ok := ex.(*InvalidTypeException)
+	if !ok {
+		panic(ex)
+	}
+	b.fail(path, invalid.Message)
+}
+
+func (b *binder) bindStruct(src interface{}, dst reflect.Value, path string) {
+	mp, ok := src.(map[string]interface{})
+	if !ok {
+		b.fail(path, "Object is not a map")
+		return
+	}
+	used := make(map[string]bool)
+	b.bindFields(mp, dst, path, used)
+	if !b.disallowUnknown {
+		return
+	}
+	keys := make([]string, 0)
+	for k := range mp {
+		if !used[k] && !(path == "" && b.pathKeys[k]) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.unknown = append(b.unknown, FieldError{Field: joinPath(path, k), Message: "unknown field"})
+	}
+}
+
+func (b *binder) bindFields(mp map[string]interface{}, dst reflect.Value, path string, used map[string]bool) {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, tagged, skip := bindFieldName(field)
+		if skip {
+			continue
+		}
+		if field.Anonymous && !tagged && field.Type.Kind() == reflect.Struct {
+			b.bindFields(mp, dst.Field(i), path, used)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		key, found := lookupKey(mp, name)
+		if !found {
+			continue
+		}
+		used[key] = true
+		b.bind(mp[key], dst.Field(i), joinPath(path, name))
+	}
+}
+
+func (b *binder) bindMap(src interface{}, dst reflect.Value, path string) {
+	mp, ok := src.(map[string]interface{})
+	if !ok {
+		b.fail(path, "Object is not a map")
+		return
+	}
+	if dst.Type().Key().Kind() != reflect.String {
+		b.fail(path, fmt.Sprintf("cannot bind into %s", dst.Type()))
+		return
+	}
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMap(dst.Type()))
+	}
+	for k, v := range mp {
+		elem := reflect.New(dst.Type().Elem()).Elem()
+		b.bind(v, elem, joinPath(path, k))
+		dst.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), elem)
+	}
+}
+
+func (b *binder) bindSlice(src interface{}, dst reflect.Value, path string) {
+	ar, ok := src.([]interface{})
+	if !ok {
+		b.fail(path, "Object is not an array")
+		return
+	}
+	slice := reflect.MakeSlice(dst.Type(), len(ar), len(ar))
+	for i, v := range ar {
+		b.bind(v, slice.Index(i), fmt.Sprintf("%s[%d]", path, i))
+	}
+	dst.Set(slice)
+}
+
+func (b *binder) bindArray(src interface{}, dst reflect.Value, path string) {
+	ar, ok := src.([]interface{})
+	if !ok {
+		b.fail(path, "Object is not an array")
+		return
+	}
+	if len(ar) > dst.Len() {
+		b.fail(path, fmt.Sprintf("array has more than %d elements", dst.Len()))
+		return
+	}
+	for i, v := range ar {
+		b.bind(v, dst.Index(i), fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+func bindFieldName(field reflect.StructField) (name string, tagged bool, skip bool) {
+	tag, ok := field.Tag.Lookup("param")
+	if !ok {
+		tag, ok = field.Tag.Lookup("json")
+	}
+	name = strings.Split(tag, ",")[0]
+	if name == "-" {
+		return "", true, true
+	}
+	if name == "" {
+		return field.Name, false, false
+	}
+	return name, ok, false
+}
+
+func lookupKey(mp map[string]interface{}, name string) (string, bool) {
+	if _, ok := mp[name]; ok {
+		return name, true
+	}
+	for k := range mp {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,104 @@
+package m3lshttp
+
+import (
+	"testing"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindAddress struct {
+	City string `json:"city"`
+	Zip  int    `param:"zip_code"`
+}
+
+type bindAudit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type bindUser struct {
+	bindAudit
+	Email     string                 `json:"email"`
+	Age       uint8                  `json:"age"`
+	Score     float64                `json:"score"`
+	Admin     bool                   `json:"admin"`
+	Address   *bindAddress           `json:"address"`
+	Tags      []string               `json:"tags"`
+	Limits    map[string]int         `json:"limits"`
+	Extra     interface{}            `json:"extra"`
+	Ignored   string                 `json:"-"`
+	Nicknames [2]string              `json:"nicknames"`
+	Raw       map[string]interface{} `json:"raw"`
+}
+
+func TestBind(t *testing.T) {
+	p := newParams(map[string]interface{}{
+		"created_by": "admin",
+		"email":      "a@b.c",
+		"age":        "42",
+		"score":      float64(9.5),
+		"admin":      "true",
+		"address":    map[string]interface{}{"city": "Cairo", "zip_code": float64(11511)},
+		"tags":       []interface{}{"a", "b"},
+		"limits":     map[string]interface{}{"daily": "5"},
+		"extra":      []interface{}{float64(1)},
+		"Ignored":    "x",
+		"nicknames":  []interface{}{"7amada"},
+		"raw":        map[string]interface{}{"k": "v"},
+	})
+	var u bindUser
+	p.GetObject("_data").Bind(&u)
+
+	assert.Equal(t, "admin", u.CreatedBy)
+	assert.Equal(t, "a@b.c", u.Email)
+	assert.Equal(t, uint8(42), u.Age)
+	assert.Equal(t, 9.5, u.Score)
+	assert.True(t, u.Admin)
+	require.NotNil(t, u.Address)
+	assert.Equal(t, bindAddress{City: "Cairo", Zip: 11511}, *u.Address)
+	assert.Equal(t, []string{"a", "b"}, u.Tags)
+	assert.Equal(t, map[string]int{"daily": 5}, u.Limits)
+	assert.Equal(t, []interface{}{float64(1)}, u.Extra)
+	assert.Empty(t, u.Ignored)
+	assert.Equal(t, [2]string{"7amada", ""}, u.Nicknames)
+	assert.Equal(t, map[string]interface{}{"k": "v"}, u.Raw)
+}
+
+func TestBindCaseInsensitiveFallback(t *testing.T) {
+	var dst struct {
+		Name string
+	}
+	newParams(map[string]interface{}{"name": "7amada"}).GetObject("_data").Bind(&dst)
+	assert.Equal(t, "7amada", dst.Name)
+}
+
+func TestBindReportsAllErrors(t *testing.T) {
+	p := newParams(map[string]interface{}{
+		"email":   float64(5),
+		"age":     "300",
+		"address": map[string]interface{}{"zip_code": "abc"},
+		"tags":    []interface{}{"a", float64(1)},
+	})
+	var u bindUser
+	ex := m3lsh.Try(func() {
+		p.GetObject("_data").Bind(&u)
+		t.Error("Bind errors not reported")
+	})
+	require.IsType(t, &UnprocessableEntity{}, ex)
+	errors := ex.(*UnprocessableEntity).Fields["errors"].([]FieldError)
+	fields := make([]string, 0)
+	for _, e := range errors {
+		fields = append(fields, e.Field)
+	}
+	assert.ElementsMatch(t, []string{"email", "age", "address.zip_code", "tags[1]"}, fields)
+}
+
+func TestBindRequiresPointer(t *testing.T) {
+	var u bindUser
+	ex := m3lsh.Try(func() {
+		newParams(map[string]interface{}{}).Bind(u)
+		t.Error("Non-pointer destination not reported")
+	})
+	assert.IsType(t, &InvalidTypeException{}, ex)
+}
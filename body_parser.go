@@ -1,27 +1,40 @@
 package m3lshttp
 
 import (
+	"bytes"
 	"encoding/json"
 )
 
 func parseBody(req Request) Params {
+	return parseBodyWith(req, nil)
+}
+
+func parseBodyWith(req Request, options *DecodeOptions) Params {
 	var parsedBody interface{}
 
+	checkBodySize(req, options)
 	contentType := req.ContentType()
 	switch contentType {
 	case "application/json":
-		parsedBody = parseJson(req.Body())
+		if needsStrictJson(options) {
+			parsedBody = parseStrictJson(req.Body(), options)
+		} else {
+			parsedBody = parseJson(req.Body())
+		}
 	case "multipart/form-data":
 		parsedBody = mapToInterfaceMap(req.MultipartForm())
 	default:
 		parsedBody = string(req.Body())
 	}
 
-	return newParams(parsedBody)
+	return &ParamsWrapper{object: cleanObject(parsedBody), options: options, root: true}
 }
 
+// numbers are kept as json.Number so large integers survive until an accessor converts them
 func parseJson(body []byte) interface{} {
 	var ret interface{}
-	json.Unmarshal(body, &ret)
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	decoder.Decode(&ret)
 	return ret
 }
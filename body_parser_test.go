@@ -68,3 +68,12 @@ func TestParseBodyString(t *testing.T) {
 	require.True(t, ok, "Data object not string")
 	assert.Equal(t, "7amada", dataObjectValue)
 }
+
+func TestParseBodyJsonKeepsNumberPrecision(t *testing.T) {
+	testReq := new(RequestMock)
+	testReq.On("ContentType").Return("application/json")
+	testReq.On("Body").Return([]byte("{\"id\": 9007199254740993}"))
+	output := parseBody(testReq)
+
+	assert.Equal(t, int64(9007199254740993), output.GetObject("_data").GetObject("id").Long())
+}
@@ -0,0 +1,107 @@
+package m3lshttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/mohamed-essam/m3lsh"
+)
+
+// DecodeOptions tightens request body decoding; the zero value keeps the lenient defaults
+type DecodeOptions struct {
+	DisallowUnknownFields bool
+	DisallowDuplicateKeys bool
+	MaxDepth              int
+	MaxBodySize           int
+}
+
+func WithDecodeOptions(options DecodeOptions) RouteOption {
+	return func(rt *route) {
+		next := rt.handler
+		rt.handler = func(r Request) {
+			r.setDecodeOptions(&options)
+			next(r)
+		}
+	}
+}
+
+func (h *HttpHandler) SetDecodeOptions(options DecodeOptions) {
+	h.decodeOptions = &options
+}
+
+func checkBodySize(req Request, options *DecodeOptions) {
+	if options != nil && options.MaxBodySize > 0 && len(req.Body()) > options.MaxBodySize {
+		m3lsh.Throw(&PayloadTooLarge{}, fmt.Sprintf("Request body exceeds %d bytes", options.MaxBodySize))
+	}
+}
+
+func needsStrictJson(options *DecodeOptions) bool {
+	return options != nil && (options.DisallowDuplicateKeys || options.MaxDepth > 0)
+}
+
+type strictDecoder struct {
+	decoder *json.Decoder
+	options *DecodeOptions
+}
+
+// parseStrictJson walks the token stream so duplicate keys and nesting depth can be checked as they are read
+func parseStrictJson(body []byte, options *DecodeOptions) interface{} {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	d := &strictDecoder{decoder: decoder, options: options}
+	value := d.value(0)
+	if _, err := decoder.Token(); err != io.EOF {
+		throwInvalidJson()
+	}
+	return value
+}
+
+func (d *strictDecoder) value(depth int) interface{} {
+	tok := d.token()
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok
+	}
+	if d.options.MaxDepth > 0 && depth >= d.options.MaxDepth {
+		m3lsh.Throw(&BadRequest{}, fmt.Sprintf("Request body is nested deeper than %d levels", d.options.MaxDepth))
+	}
+	switch delim {
+	case '{':
+		obj := make(map[string]interface{})
+		for d.decoder.More() {
+			key, _ := d.token().(string)
+			if _, duplicate := obj[key]; duplicate && d.options.DisallowDuplicateKeys {
+				m3lsh.Throw(&BadRequest{}, fmt.Sprintf("Duplicate key %q in request body", key))
+			}
+			obj[key] = d.value(depth + 1)
+		}
+		d.token()
+		return obj
+	case '[':
+		arr := make([]interface{}, 0)
+		for d.decoder.More() {
+			arr = append(arr, d.value(depth+1))
+		}
+		d.token()
+		return arr
+	}
+	throwInvalidJson()
+	return nil
+}
+
+func (d *strictDecoder) token() json.Token {
+	tok, err := d.decoder.Token()
+	if err != nil {
+		throwInvalidJson()
+	}
+	return tok
+}
+
+func throwInvalidJson() {
+	m3lsh.Throw(&BadRequest{}, "Request body is not valid JSON")
+}
@@ -0,0 +1,91 @@
+package m3lshttp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assertBadRequest(t *testing.T, message string, fn func()) {
+	ex := m3lsh.Try(func() {
+		fn()
+		t.Error("Not panicked")
+	})
+	require.IsType(t, &BadRequest{}, ex)
+	assert.Equal(t, message, ex.(*BadRequest).Message)
+}
+
+func TestParseStrictJson(t *testing.T) {
+	options := &DecodeOptions{DisallowDuplicateKeys: true, MaxDepth: 2}
+	value := parseStrictJson([]byte(`{"a": [1, "x", true, null], "b": {}}`), options)
+	assert.Equal(t, map[string]interface{}{
+		"a": []interface{}{json.Number("1"), "x", true, nil},
+		"b": map[string]interface{}{},
+	}, value)
+	assert.Nil(t, parseStrictJson([]byte("  "), options))
+
+	assertBadRequest(t, `Duplicate key "a" in request body`, func() {
+		parseStrictJson([]byte(`{"a": 1, "a": 2}`), options)
+	})
+	assertBadRequest(t, "Request body is nested deeper than 2 levels", func() {
+		parseStrictJson([]byte(`{"a": [[1]]}`), options)
+	})
+	assertBadRequest(t, "Request body is not valid JSON", func() {
+		parseStrictJson([]byte(`{"a": 1} {}`), options)
+	})
+	assertBadRequest(t, "Request body is not valid JSON", func() {
+		parseStrictJson([]byte(`{"a": `), options)
+	})
+}
+
+func TestDuplicateKeysAllowedByDefault(t *testing.T) {
+	value := parseStrictJson([]byte(`{"a": 1, "a": 2}`), &DecodeOptions{MaxDepth: 5})
+	assert.Equal(t, map[string]interface{}{"a": json.Number("2")}, value)
+}
+
+func TestBindDisallowUnknownFields(t *testing.T) {
+	p := &ParamsWrapper{
+		object:  map[string]interface{}{"created_by": "a", "email": "a@b.c", "unexpected": 1, "address": map[string]interface{}{"city": "x", "street": "y"}},
+		options: &DecodeOptions{DisallowUnknownFields: true},
+	}
+	ex := m3lsh.Try(func() {
+		var dst bindUser
+		p.Bind(&dst)
+		t.Error("Not panicked")
+	})
+	require.IsType(t, &BadRequest{}, ex)
+	assert.Equal(t, []FieldError{{Field: "address.street", Message: "unknown field"}, {Field: "unexpected", Message: "unknown field"}}, ex.(*BadRequest).Fields["errors"])
+
+	var dst bindUser
+	p.options = nil
+	p.Bind(&dst)
+	assert.Equal(t, "x", dst.Address.City)
+}
+
+func TestGlobalDecodeOptions(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.SetDecodeOptions(DecodeOptions{MaxBodySize: 10})
+	handler.POST("/api/users", func(r Request) {
+		r.Params()
+	})
+	status, _ := tempServer(handler.handle, "/api/users", `{"name": "too long"}`, "POST", t)
+	assert.Equal(t, 413, status)
+}
+
+func TestRouteDecodeOptions(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.SetDecodeOptions(DecodeOptions{MaxBodySize: 10})
+	handler.POST("/api/users", func(r Request, in createUser) (user, error) {
+		return user{Name: in.Name}, nil
+	}, WithDecodeOptions(DecodeOptions{DisallowUnknownFields: true, DisallowDuplicateKeys: true}))
+
+	status, _ := tempServer(handler.handle, "/api/users", `{"name": "a", "email": "a@b.c"}`, "POST", t)
+	assert.Equal(t, 200, status)
+	status, _ = tempServer(handler.handle, "/api/users", `{"name": "a", "email": "a@b.c", "admin": true}`, "POST", t)
+	assert.Equal(t, 400, status)
+	status, _ = tempServer(handler.handle, "/api/users", `{"name": "a", "name": "b", "email": "a@b.c"}`, "POST", t)
+	assert.Equal(t, 400, status)
+}
@@ -0,0 +1,183 @@
+package m3lshttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sync"
+
+	"github.com/mohamed-essam/m3lsh"
+)
+
+type Encoder interface {
+	ContentType() string
+	Encode(data interface{}) ([]byte, error)
+}
+
+type JsonEncoder struct {
+	Indent       string
+	NoHtmlEscape bool
+}
+
+type XmlEncoder struct{}
+
+type TextEncoder struct{}
+
+type HtmlEncoder struct{}
+
+type MsgPackEncoder struct{}
+
+type ProtobufEncoder struct{}
+
+type RawEncoder struct{}
+
+// protoMarshaler is implemented by generated protobuf messages
+type protoMarshaler interface {
+	Marshal() ([]byte, error)
+}
+
+var (
+	encodersLock sync.RWMutex
+	encoders     = map[ResponseType]Encoder{
+		Json:     JsonEncoder{},
+		Xml:      XmlEncoder{},
+		Text:     TextEncoder{},
+		Html:     HtmlEncoder{},
+		MsgPack:  MsgPackEncoder{},
+		Protobuf: ProtobufEncoder{},
+		Raw:      RawEncoder{},
+	}
+)
+
+func RegisterEncoder(responseType ResponseType, encoder Encoder) {
+	encodersLock.Lock()
+	defer encodersLock.Unlock()
+	encoders[responseType] = encoder
+}
+
+func encoderFor(responseType ResponseType) Encoder {
+	encodersLock.RLock()
+	defer encodersLock.RUnlock()
+	encoder, ok := encoders[responseType]
+	if !ok {
+		m3lsh.Throw(&InternalServerError{}, fmt.Sprintf("No encoder registered for response type %d", responseType))
+	}
+	return encoder
+}
+
+func (JsonEncoder) ContentType() string {
+	return "application/json"
+}
+
+func (e JsonEncoder) Encode(data interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(!e.NoHtmlEscape)
+	encoder.SetIndent("", e.Indent)
+	if err := encoder.Encode(data); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func (XmlEncoder) ContentType() string {
+	return "application/xml"
+}
+
+func (XmlEncoder) Encode(data interface{}) ([]byte, error) {
+	out, err := xml.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func (TextEncoder) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (TextEncoder) Encode(data interface{}) ([]byte, error) {
+	return textBytes(data), nil
+}
+
+func (HtmlEncoder) ContentType() string {
+	return "text/html; charset=utf-8"
+}
+
+func (HtmlEncoder) Encode(data interface{}) ([]byte, error) {
+	return textBytes(data), nil
+}
+
+func (MsgPackEncoder) ContentType() string {
+	return "application/msgpack"
+}
+
+func (MsgPackEncoder) Encode(data interface{}) ([]byte, error) {
+	return marshalMsgPack(data)
+}
+
+func (ProtobufEncoder) ContentType() string {
+	return "application/protobuf"
+}
+
+func (ProtobufEncoder) Encode(data interface{}) ([]byte, error) {
+	msg, ok := data.(protoMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a protobuf message", data)
+	}
+	return msg.Marshal()
+}
+
+func (RawEncoder) ContentType() string {
+	return "application/octet-stream"
+}
+
+func (RawEncoder) Encode(data interface{}) ([]byte, error) {
+	switch v := data.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	return nil, fmt.Errorf("%T cannot be written as raw bytes", data)
+}
+
+// text, HTML and raw bytes are only negotiated for data that already is text or bytes,
+// anything else would be sent as its %v dump
+func (TextEncoder) Negotiable(data interface{}) bool {
+	return isTextData(data)
+}
+
+func (HtmlEncoder) Negotiable(data interface{}) bool {
+	return isTextData(data)
+}
+
+func (RawEncoder) Negotiable(data interface{}) bool {
+	return isTextData(data)
+}
+
+func (ProtobufEncoder) Negotiable(data interface{}) bool {
+	_, ok := data.(protoMarshaler)
+	return ok
+}
+
+func isTextData(data interface{}) bool {
+	switch data.(type) {
+	case []byte, string:
+		return true
+	}
+	return false
+}
+
+func textBytes(data interface{}) []byte {
+	switch v := data.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	case nil:
+		return []byte{}
+	}
+	return []byte(fmt.Sprintf("%v", data))
+}
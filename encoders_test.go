@@ -0,0 +1,143 @@
+package m3lshttp
+
+import (
+	"testing"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+type protoMessageMock struct {
+	data []byte
+}
+
+func (m protoMessageMock) Marshal() ([]byte, error) {
+	return m.data, nil
+}
+
+type upperEncoder struct{}
+
+func (upperEncoder) ContentType() string {
+	return "text/x-upper"
+}
+
+func (upperEncoder) Encode(data interface{}) ([]byte, error) {
+	return []byte("UPPER"), nil
+}
+
+func respondWith(responseType ResponseType, data interface{}) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	req := &RequestMock{}
+	req.On("context").Return(ctx)
+	Respond(req, responseType, data)
+	return ctx
+}
+
+func TestRespondJson(t *testing.T) {
+	ctx := respondWith(Json, map[string]string{"a": "b"})
+	assert.Equal(t, "application/json", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, "{\"a\":\"b\"}", string(ctx.Response.Body()))
+}
+
+func TestRespondXml(t *testing.T) {
+	type item struct {
+		Name string `xml:"name"`
+	}
+	ctx := respondWith(Xml, item{Name: "7amada"})
+	assert.Equal(t, "application/xml", string(ctx.Response.Header.ContentType()))
+	assert.Contains(t, string(ctx.Response.Body()), "<item><name>7amada</name></item>")
+}
+
+func TestRespondText(t *testing.T) {
+	ctx := respondWith(Text, 5)
+	assert.Equal(t, "text/plain; charset=utf-8", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, "5", string(ctx.Response.Body()))
+}
+
+func TestRespondHtml(t *testing.T) {
+	ctx := respondWith(Html, "<b>hi</b>")
+	assert.Equal(t, "text/html; charset=utf-8", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, "<b>hi</b>", string(ctx.Response.Body()))
+}
+
+func TestRespondMsgPack(t *testing.T) {
+	ctx := respondWith(MsgPack, map[string]interface{}{"a": 1})
+	assert.Equal(t, "application/msgpack", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, []byte{0x81, 0xa1, 'a', 0x01}, ctx.Response.Body())
+}
+
+func TestRespondProtobuf(t *testing.T) {
+	ctx := respondWith(Protobuf, protoMessageMock{data: []byte{0x08, 0x96, 0x01}})
+	assert.Equal(t, "application/protobuf", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, []byte{0x08, 0x96, 0x01}, ctx.Response.Body())
+}
+
+func TestRespondRaw(t *testing.T) {
+	ctx := respondWith(Raw, []byte{1, 2, 3})
+	assert.Equal(t, "application/octet-stream", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, []byte{1, 2, 3}, ctx.Response.Body())
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	const upper ResponseType = 100
+	RegisterEncoder(upper, upperEncoder{})
+	ctx := respondWith(upper, "anything")
+	assert.Equal(t, "text/x-upper", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, "UPPER", string(ctx.Response.Body()))
+}
+
+func TestRespondUnknownType(t *testing.T) {
+	ex := m3lsh.Try(func() {
+		respondWith(ResponseType(-1), "anything")
+		t.Error("Unknown response type not reported")
+	})
+	require.NotNil(t, ex)
+	assert.IsType(t, &InternalServerError{}, ex)
+}
+
+func TestProtobufEncoderRejectsNonMessages(t *testing.T) {
+	_, err := ProtobufEncoder{}.Encode("7amada")
+	assert.Error(t, err)
+}
+
+func TestJsonEncoderIndent(t *testing.T) {
+	out, err := JsonEncoder{Indent: "  "}.Encode(map[string]string{"a": "b"})
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": \"b\"\n}", string(out))
+}
+
+func TestJsonEncoderHtmlEscape(t *testing.T) {
+	out, err := JsonEncoder{}.Encode("<b>")
+	require.NoError(t, err)
+	assert.Equal(t, "\"\\u003cb\\u003e\"", string(out))
+
+	out, err = JsonEncoder{NoHtmlEscape: true}.Encode("<b>")
+	require.NoError(t, err)
+	assert.Equal(t, "\"<b>\"", string(out))
+}
+
+func TestRespondEncodingError(t *testing.T) {
+	ex := m3lsh.Try(func() {
+		respondWith(Json, make(chan int))
+		t.Error("Encoding error not reported")
+	})
+	require.NotNil(t, ex)
+	require.IsType(t, &InternalServerError{}, ex)
+	assert.Error(t, ex.(*InternalServerError).Cause)
+}
+
+func TestRespondEncodingErrorStatus(t *testing.T) {
+	handler := NewHttpHandler()
+	var hookErr error
+	handler.OnError(func(r Request, err error) {
+		hookErr = err
+	})
+	handler.GET("/api/stream", func(r Request) {
+		Respond(r, Json, map[string]interface{}{"ch": make(chan int)})
+	})
+	ctx := handleRequest(handler, "GET", "/api/stream")
+	assert.Equal(t, 500, ctx.Response.StatusCode())
+	assert.Error(t, hookErr)
+}
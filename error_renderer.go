@@ -0,0 +1,54 @@
+package m3lshttp
+
+import (
+	"encoding/json"
+
+	"github.com/valyala/fasthttp"
+)
+
+type ErrorRenderer func(r Request, status int, message string, ex interface{})
+
+type problemCarrier interface {
+	problem() *Problem
+}
+
+func ProblemJsonRenderer(r Request, status int, message string, ex interface{}) {
+	body := map[string]interface{}{
+		"type":     "about:blank",
+		"title":    fasthttp.StatusMessage(status),
+		"status":   status,
+		"instance": r.Path(),
+	}
+	if message != "" {
+		body["detail"] = message
+	}
+	if carrier, ok := ex.(problemCarrier); ok {
+		p := carrier.problem()
+		for k, v := range p.Fields {
+			body[k] = v
+		}
+		if p.Type != "" {
+			body["type"] = p.Type
+		}
+		if p.Code != "" {
+			body["code"] = p.Code
+		}
+	}
+	out, err := json.Marshal(body)
+	if err != nil {
+		r.reportError(err)
+		PlainTextRenderer(r, status, message, ex)
+		return
+	}
+	ctx := r.context()
+	ctx.Response.SetStatusCode(status)
+	ctx.Response.Header.SetContentType("application/problem+json")
+	ctx.Response.SetBody(out)
+}
+
+func PlainTextRenderer(r Request, status int, message string, ex interface{}) {
+	ctx := r.context()
+	ctx.Response.SetStatusCode(status)
+	ctx.Response.Header.SetContentType("text/plain; charset=utf-8")
+	ctx.Response.SetBody([]byte(message))
+}
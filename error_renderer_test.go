@@ -0,0 +1,72 @@
+package m3lshttp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemJsonRenderer(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.POST("/api/users", func(r Request) {
+		m3lsh.Throw(&UnprocessableEntity{httpException{Problem: Problem{
+			Type:   "https://example.com/problems/validation",
+			Code:   "invalid_user",
+			Fields: map[string]interface{}{"errors": []string{"email is required"}},
+		}}}, "User is invalid")
+	})
+	ctx := handleRequest(handler, "POST", "/api/users")
+	assert.Equal(t, 422, ctx.Response.StatusCode())
+	assert.Equal(t, "application/problem+json", string(ctx.Response.Header.ContentType()))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(ctx.Response.Body(), &body))
+	assert.Equal(t, "https://example.com/problems/validation", body["type"])
+	assert.Equal(t, "Unprocessable Entity", body["title"])
+	assert.Equal(t, float64(422), body["status"])
+	assert.Equal(t, "User is invalid", body["detail"])
+	assert.Equal(t, "/api/users", body["instance"])
+	assert.Equal(t, "invalid_user", body["code"])
+	assert.Equal(t, []interface{}{"email is required"}, body["errors"])
+}
+
+func TestProblemJsonRendererDefaults(t *testing.T) {
+	handler := NewHttpHandler()
+	ctx := handleRequest(handler, "GET", "/missing")
+	assert.Equal(t, 404, ctx.Response.StatusCode())
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(ctx.Response.Body(), &body))
+	assert.Equal(t, "about:blank", body["type"])
+	assert.Equal(t, "Not Found", body["title"])
+	assert.NotContains(t, body, "detail")
+	assert.NotContains(t, body, "code")
+}
+
+func TestPlainTextRenderer(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.SetErrorRenderer(PlainTextRenderer)
+	handler.GET("/api/private", func(r Request) {
+		m3lsh.Throw(&Forbidden{}, "No access")
+	})
+	ctx := handleRequest(handler, "GET", "/api/private")
+	assert.Equal(t, 403, ctx.Response.StatusCode())
+	assert.Equal(t, "No access", string(ctx.Response.Body()))
+}
+
+func TestCustomErrorRenderer(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.SetErrorRenderer(func(r Request, status int, message string, ex interface{}) {
+		r.context().Response.SetStatusCode(status)
+		Respond(r, Json, map[string]string{"error": message})
+	})
+	handler.GET("/api/private", func(r Request) {
+		m3lsh.Throw(&Unauthorized{}, "Login first")
+	})
+	ctx := handleRequest(handler, "GET", "/api/private")
+	assert.Equal(t, 401, ctx.Response.StatusCode())
+	assert.Equal(t, "{\"error\":\"Login first\"}", string(ctx.Response.Body()))
+}
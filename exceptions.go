@@ -1,39 +1,246 @@
 package m3lshttp
 
-import "github.com/mohamed-essam/m3lsh"
+import (
+	"strconv"
+	"time"
 
-type BadRequest struct {
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/valyala/fasthttp"
+)
+
+type Problem struct {
+	Type   string
+	Code   string
+	Fields map[string]interface{}
+}
+
+// httpException is embedded by every HTTP exception so they share one Error method
+type httpException struct {
 	m3lsh.BaseException
+	Problem
+}
+
+func (e *httpException) Error() string {
+	return e.Message
+}
+
+type BadRequest struct {
+	httpException
 }
 
 type Unauthorized struct {
-	m3lsh.BaseException
+	httpException
 }
 
 type PaymentRequired struct {
-	m3lsh.BaseException
+	httpException
 }
 
 type Forbidden struct {
-	m3lsh.BaseException
+	httpException
 }
 
 type NotFound struct {
-	m3lsh.BaseException
+	httpException
 }
 
 type MethodNotAllowed struct {
-	m3lsh.BaseException
+	httpException
+}
+
+type NotAcceptable struct {
+	httpException
 }
 
 type TimedOut struct {
-	m3lsh.BaseException
+	httpException
+}
+
+type Conflict struct {
+	httpException
+}
+
+type Gone struct {
+	httpException
+}
+
+type PreconditionFailed struct {
+	httpException
+}
+
+type PayloadTooLarge struct {
+	httpException
+	RetryAfter time.Duration
+}
+
+type UnsupportedMediaType struct {
+	httpException
 }
 
 type UnprocessableEntity struct {
-	m3lsh.BaseException
+	httpException
+}
+
+type TooManyRequests struct {
+	httpException
+	RetryAfter time.Duration
 }
 
 type InternalServerError struct {
-	m3lsh.BaseException
+	httpException
+	Cause error
+}
+
+type NotImplemented struct {
+	httpException
+}
+
+type BadGateway struct {
+	httpException
+}
+
+type ServiceUnavailable struct {
+	httpException
+	RetryAfter time.Duration
+}
+
+type GatewayTimeout struct {
+	httpException
+}
+
+type ResponseHeaderSetter interface {
+	SetResponseHeaders(header *fasthttp.ResponseHeader)
+}
+
+func newHttpException(message string) httpException {
+	return httpException{BaseException: m3lsh.BaseException{Message: message}}
+}
+
+func NewBadRequest(message string) *BadRequest {
+	return &BadRequest{httpException: newHttpException(message)}
+}
+
+func NewUnauthorized(message string) *Unauthorized {
+	return &Unauthorized{httpException: newHttpException(message)}
+}
+
+func NewPaymentRequired(message string) *PaymentRequired {
+	return &PaymentRequired{httpException: newHttpException(message)}
+}
+
+func NewForbidden(message string) *Forbidden {
+	return &Forbidden{httpException: newHttpException(message)}
+}
+
+func NewNotFound(message string) *NotFound {
+	return &NotFound{httpException: newHttpException(message)}
+}
+
+func NewMethodNotAllowed(message string) *MethodNotAllowed {
+	return &MethodNotAllowed{httpException: newHttpException(message)}
+}
+
+func NewNotAcceptable(message string) *NotAcceptable {
+	return &NotAcceptable{httpException: newHttpException(message)}
+}
+
+func NewTimedOut(message string) *TimedOut {
+	return &TimedOut{httpException: newHttpException(message)}
+}
+
+func NewConflict(message string) *Conflict {
+	return &Conflict{httpException: newHttpException(message)}
+}
+
+func NewGone(message string) *Gone {
+	return &Gone{httpException: newHttpException(message)}
+}
+
+func NewPreconditionFailed(message string) *PreconditionFailed {
+	return &PreconditionFailed{httpException: newHttpException(message)}
+}
+
+func NewPayloadTooLarge(message string) *PayloadTooLarge {
+	return &PayloadTooLarge{httpException: newHttpException(message)}
+}
+
+func NewUnsupportedMediaType(message string) *UnsupportedMediaType {
+	return &UnsupportedMediaType{httpException: newHttpException(message)}
+}
+
+func NewUnprocessableEntity(message string) *UnprocessableEntity {
+	return &UnprocessableEntity{httpException: newHttpException(message)}
+}
+
+func NewTooManyRequests(message string) *TooManyRequests {
+	return &TooManyRequests{httpException: newHttpException(message)}
+}
+
+func NewInternalServerError(message string) *InternalServerError {
+	return &InternalServerError{httpException: newHttpException(message)}
+}
+
+func NewNotImplemented(message string) *NotImplemented {
+	return &NotImplemented{httpException: newHttpException(message)}
+}
+
+func NewBadGateway(message string) *BadGateway {
+	return &BadGateway{httpException: newHttpException(message)}
+}
+
+func NewServiceUnavailable(message string) *ServiceUnavailable {
+	return &ServiceUnavailable{httpException: newHttpException(message)}
+}
+
+func NewGatewayTimeout(message string) *GatewayTimeout {
+	return &GatewayTimeout{httpException: newHttpException(message)}
+}
+
+func (p *Problem) problem() *Problem {
+	return p
+}
+
+func (h *HttpHandler) mapHttpExceptions() {
+	h.MapException(&BadRequest{}, 400)
+	h.MapException(&Unauthorized{}, 401)
+	h.MapException(&PaymentRequired{}, 402)
+	h.MapException(&Forbidden{}, 403)
+	h.MapException(&NotFound{}, 404)
+	h.MapException(&MethodNotAllowed{}, 405)
+	h.MapException(&NotAcceptable{}, 406)
+	h.MapException(&TimedOut{}, 408)
+	h.MapException(&Conflict{}, 409)
+	h.MapException(&Gone{}, 410)
+	h.MapException(&PreconditionFailed{}, 412)
+	h.MapException(&PayloadTooLarge{}, 413)
+	h.MapException(&UnsupportedMediaType{}, 415)
+	h.MapException(&UnprocessableEntity{}, 422)
+	h.MapException(&TooManyRequests{}, 429)
+	h.MapException(&InternalServerError{}, 500)
+	h.MapException(&NotImplemented{}, 501)
+	h.MapException(&BadGateway{}, 502)
+	h.MapException(&ServiceUnavailable{}, 503)
+	h.MapException(&GatewayTimeout{}, 504)
+}
+
+// the client may still be sending the rest of an oversized body, so the connection is not reused
+func (e *PayloadTooLarge) SetResponseHeaders(header *fasthttp.ResponseHeader) {
+	header.SetConnectionClose()
+	setRetryAfter(header, e.RetryAfter)
+}
+
+func (e *TooManyRequests) SetResponseHeaders(header *fasthttp.ResponseHeader) {
+	setRetryAfter(header, e.RetryAfter)
+}
+
+func (e *ServiceUnavailable) SetResponseHeaders(header *fasthttp.ResponseHeader) {
+	setRetryAfter(header, e.RetryAfter)
+}
+
+func setRetryAfter(header *fasthttp.ResponseHeader, after time.Duration) {
+	if after <= 0 {
+		return
+	}
+	seconds := int64((after + time.Second - 1) / time.Second)
+	header.Set("Retry-After", strconv.FormatInt(seconds, 10))
 }
@@ -0,0 +1,82 @@
+package m3lshttp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpExceptionStatuses(t *testing.T) {
+	cases := []struct {
+		throw  func()
+		status int
+	}{
+		{func() { m3lsh.Throw(&Conflict{}, "") }, 409},
+		{func() { m3lsh.Throw(&Gone{}, "") }, 410},
+		{func() { m3lsh.Throw(&PreconditionFailed{}, "") }, 412},
+		{func() { m3lsh.Throw(&PayloadTooLarge{}, "") }, 413},
+		{func() { m3lsh.Throw(&UnsupportedMediaType{}, "") }, 415},
+		{func() { m3lsh.Throw(&TooManyRequests{}, "") }, 429},
+		{func() { m3lsh.Throw(&NotImplemented{}, "") }, 501},
+		{func() { m3lsh.Throw(&BadGateway{}, "") }, 502},
+		{func() { m3lsh.Throw(&ServiceUnavailable{}, "") }, 503},
+		{func() { m3lsh.Throw(&GatewayTimeout{}, "") }, 504},
+	}
+	for _, c := range cases {
+		handler := NewHttpHandler()
+		throw := c.throw
+		handler.GET("/api/thing", func(r Request) {
+			throw()
+		})
+		ctx := handleRequest(handler, "GET", "/api/thing")
+		assert.Equal(t, c.status, ctx.Response.StatusCode())
+	}
+}
+
+func TestTooManyRequestsRetryAfter(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.GET("/api/thing", func(r Request) {
+		m3lsh.Throw(&TooManyRequests{RetryAfter: 1500 * time.Millisecond}, "Slow down")
+	})
+	ctx := handleRequest(handler, "GET", "/api/thing")
+	assert.Equal(t, 429, ctx.Response.StatusCode())
+	assert.Equal(t, "2", string(ctx.Response.Header.Peek("Retry-After")))
+}
+
+func TestServiceUnavailableRetryAfter(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.GET("/api/thing", func(r Request) {
+		m3lsh.Throw(&ServiceUnavailable{RetryAfter: time.Minute}, "Maintenance")
+	})
+	ctx := handleRequest(handler, "GET", "/api/thing")
+	assert.Equal(t, 503, ctx.Response.StatusCode())
+	assert.Equal(t, "60", string(ctx.Response.Header.Peek("Retry-After")))
+}
+
+func TestServiceUnavailableWithoutRetryAfter(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.GET("/api/thing", func(r Request) {
+		m3lsh.Throw(&ServiceUnavailable{}, "Maintenance")
+	})
+	ctx := handleRequest(handler, "GET", "/api/thing")
+	assert.Empty(t, ctx.Response.Header.Peek("Retry-After"))
+}
+
+func TestPayloadTooLargeClosesConnection(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.POST("/api/upload", func(r Request) {
+		m3lsh.Throw(&PayloadTooLarge{}, "Too big")
+	})
+	ctx := handleRequest(handler, "POST", "/api/upload")
+	assert.Equal(t, 413, ctx.Response.StatusCode())
+	assert.True(t, ctx.Response.Header.ConnectionClose())
+}
+
+func TestExceptionConstructors(t *testing.T) {
+	var err error = NewNotFound("No such user")
+	assert.Equal(t, "No such user", err.Error())
+	assert.Equal(t, "Try later", NewServiceUnavailable("Try later").Message)
+	assert.Equal(t, "Oops", exceptionMessage(NewInternalServerError("Oops")))
+}
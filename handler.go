@@ -1,7 +1,11 @@
 package m3lshttp
 
 import (
-	"encoding/json"
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"reflect"
 
 	"github.com/mohamed-essam/m3lsh"
 
@@ -9,92 +13,174 @@ import (
 )
 
 type HttpHandler struct {
-	tree *urlTree
+	tree            *urlTree
+	errorHook       ErrorHook
+	errorRenderer   ErrorRenderer
+	exceptionStatus map[reflect.Type]int
+	decodeOptions   *DecodeOptions
+	config          ServerConfig
+	// maxRouteBodySize is the largest WithMaxBodySize given to any route
+	maxRouteBodySize int
+	state            *serverState
 }
 
 type handler func(Request)
 
+type ErrorHandler func(Request) error
+
+type InvalidHandlerException struct {
+	m3lsh.BaseException
+}
+
+// handlerError carries an error returned by an ErrorHandler up to recoverPanic
+type handlerError struct {
+	err error
+}
+
+type ErrorHook func(r Request, err error)
+
+// LogErrors is the default ErrorHook; a PanicError is logged with its stack trace
+func LogErrors(r Request, err error) {
+	ctx := r.context()
+	log.Printf("m3lshttp: %s %s: %s", ctx.Method(), ctx.Path(), err)
+}
+
 func NewHttpHandler() *HttpHandler {
-	return &HttpHandler{tree: newUrlTree()}
+	h := &HttpHandler{tree: newUrlTree(), errorRenderer: ProblemJsonRenderer, exceptionStatus: make(map[reflect.Type]int), state: newServerState(), errorHook: LogErrors}
+	h.mapHttpExceptions()
+	h.MapException(&InvalidTypeException{}, 422)
+	h.MapException(&PathNotFoundException{}, 422)
+	h.MapException(&IndexOutOfRangeException{}, 422)
+	return h
+}
+
+func (h *HttpHandler) POST(path string, fn interface{}, options ...RouteOption) {
+	h.addRoute(path, "POST", fn, options)
 }
 
-func (h *HttpHandler) POST(path string, fn handler) {
-	h.tree.addPath(path, "POST", fn)
+func (h *HttpHandler) GET(path string, fn interface{}, options ...RouteOption) {
+	h.addRoute(path, "GET", fn, options)
 }
 
-func (h *HttpHandler) GET(path string, fn handler) {
-	h.tree.addPath(path, "GET", fn)
+func (h *HttpHandler) PUT(path string, fn interface{}, options ...RouteOption) {
+	h.addRoute(path, "PUT", fn, options)
 }
 
-func (h *HttpHandler) PUT(path string, fn handler) {
-	h.tree.addPath(path, "PUT", fn)
+func (h *HttpHandler) DELETE(path string, fn interface{}, options ...RouteOption) {
+	h.addRoute(path, "DELETE", fn, options)
 }
 
-func (h *HttpHandler) DELETE(path string, fn handler) {
-	h.tree.addPath(path, "DELETE", fn)
+func (h *HttpHandler) PATCH(path string, fn interface{}, options ...RouteOption) {
+	h.addRoute(path, "PATCH", fn, options)
 }
 
-func (h *HttpHandler) PATCH(path string, fn handler) {
-	h.tree.addPath(path, "PATCH", fn)
+type route struct {
+	handler     handler
+	maxBodySize int
+}
+
+type RouteOption func(rt *route)
+
+func (h *HttpHandler) addRoute(path, method string, fn interface{}, options []RouteOption) {
+	rt := &route{handler: toHandler(fn)}
+	for _, option := range options {
+		option(rt)
+	}
+	if rt.maxBodySize > h.maxRouteBodySize {
+		h.maxRouteBodySize = rt.maxBodySize
+	}
+	h.tree.addPath(path, method, h.limitBodySize(rt.handler, rt.maxBodySize))
+}
+
+func toHandler(fn interface{}) handler {
+	switch f := fn.(type) {
+	case handler:
+		return f
+	case func(Request):
+		return f
+	case ErrorHandler:
+		return errorHandler(f)
+	case func(Request) error:
+		return errorHandler(f)
+	}
+	if isTypedHandler(fn) {
+		return typedHandler(fn)
+	}
+	m3lsh.Throw(&InvalidHandlerException{}, fmt.Sprintf("Unsupported handler type %T", fn))
+	return nil
+}
+
+func errorHandler(fn ErrorHandler) handler {
+	return func(r Request) {
+		if err := fn(r); err != nil {
+			panic(handlerError{err: err})
+		}
+	}
+}
+
+// OnError replaces LogErrors; a nil hook drops the errors
+func (h *HttpHandler) OnError(hook ErrorHook) {
+	h.errorHook = hook
+}
+
+func (h *HttpHandler) SetErrorRenderer(renderer ErrorRenderer) {
+	h.errorRenderer = renderer
 }
 
 func (h HttpHandler) handle(ctx *fasthttp.RequestCtx) {
-	m3lsh.TryCatch(func() {
-		h.tree.handle(newRequest(ctx))
-	}, m3lsh.Catcher(&BadRequest{}, func(e interface{}) {
-		ex := e.(*BadRequest)
-		ctx.Response.SetStatusCode(400)
-		ctx.Response.SetBody([]byte(ex.Message))
-	}), m3lsh.Catcher(&Unauthorized{}, func(e interface{}) {
-		ex := e.(*Unauthorized)
-		ctx.Response.SetStatusCode(401)
-		ctx.Response.SetBody([]byte(ex.Message))
-	}), m3lsh.Catcher(&PaymentRequired{}, func(e interface{}) {
-		ex := e.(*PaymentRequired)
-		ctx.Response.SetStatusCode(402)
-		ctx.Response.SetBody([]byte(ex.Message))
-	}), m3lsh.Catcher(&Forbidden{}, func(e interface{}) {
-		ex := e.(*Forbidden)
-		ctx.Response.SetStatusCode(403)
-		ctx.Response.SetBody([]byte(ex.Message))
-	}), m3lsh.Catcher(&NotFound{}, func(e interface{}) {
-		ex := e.(*NotFound)
-		ctx.Response.SetStatusCode(404)
-		ctx.Response.SetBody([]byte(ex.Message))
-	}), m3lsh.Catcher(&MethodNotAllowed{}, func(e interface{}) {
-		ex := e.(*MethodNotAllowed)
-		ctx.Response.SetStatusCode(405)
-		ctx.Response.SetBody([]byte(ex.Message))
-	}), m3lsh.Catcher(&TimedOut{}, func(e interface{}) {
-		ex := e.(*TimedOut)
-		ctx.Response.SetStatusCode(408)
-		ctx.Response.SetBody([]byte(ex.Message))
-	}), m3lsh.Catcher(&UnprocessableEntity{}, func(e interface{}) {
-		ex := e.(*UnprocessableEntity)
-		ctx.Response.SetStatusCode(422)
-		ctx.Response.SetBody([]byte(ex.Message))
-	}), m3lsh.Catcher(&InternalServerError{}, func(e interface{}) {
-		ex := e.(*InternalServerError)
-		ctx.Response.SetStatusCode(500)
-		ctx.Response.SetBody([]byte(ex.Message))
-	}))
-}
-
-func (h HttpHandler) ListenAndServe(port string) error {
-	return fasthttp.ListenAndServe(port, h.handle)
+	req := newRequest(ctx)
+	req.errorHook = h.errorHook
+	req.decodeOptions = h.decodeOptions
+	defer h.recoverPanic(req)
+	h.tree.handle(req)
+}
+
+func (h HttpHandler) renderError(r Request, status int, message string, ex interface{}) {
+	h.errorRenderer(r, status, message, ex)
+}
+
+func (h *HttpHandler) ListenAndServe(port string) error {
+	ln, err := net.Listen("tcp4", port)
+	if err != nil {
+		return err
+	}
+	return h.Serve(ln)
 }
 
 type ResponseType int
 
 const (
-	Json = iota
+	Json ResponseType = iota
+	Xml
+	Text
+	Html
+	MsgPack
+	Protobuf
+	Raw
 )
 
 func Respond(r Request, responseType ResponseType, data interface{}) {
-	var writtenData []byte
-	switch responseType {
-	case Json:
-		writtenData, _ = json.Marshal(data)
+	writeResponse(r, encoderFor(responseType), data)
+}
+
+func writeResponse(r Request, encoder Encoder, data interface{}) {
+	writtenData, err := encoder.Encode(data)
+	if err != nil {
+		m3lsh.Throw(&InternalServerError{Cause: err}, "Response could not be encoded")
 	}
+	r.context().Response.Header.SetContentType(encoder.ContentType())
 	r.context().Response.SetBody(writtenData)
 }
+
+// fn runs after the handler has returned, so its errors and panics can only be reported through the error hook
+func RespondStream(r Request, contentType string, fn func(w *bufio.Writer) error) {
+	ctx := r.context()
+	ctx.Response.Header.SetContentType(contentType)
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer recoverStream(r)
+		if err := fn(w); err != nil {
+			r.reportError(err)
+		}
+		w.Flush()
+	})
+}
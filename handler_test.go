@@ -0,0 +1,80 @@
+package m3lshttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandlerSuccess(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.GET("/api/users", func(r Request) error {
+		Respond(r, Json, []string{"a"})
+		return nil
+	})
+	ctx := handleRequest(handler, "GET", "/api/users")
+	assert.Equal(t, 200, ctx.Response.StatusCode())
+	assert.Equal(t, "[\"a\"]", string(ctx.Response.Body()))
+}
+
+func TestErrorHandlerHttpError(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.GET("/api/users/:id", func(r Request) error {
+		return NewNotFound("No such user")
+	})
+	ctx := handleRequest(handler, "GET", "/api/users/5")
+	assert.Equal(t, 404, ctx.Response.StatusCode())
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(ctx.Response.Body(), &body))
+	assert.Equal(t, "No such user", body["detail"])
+}
+
+func TestErrorHandlerWrappedHttpError(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.PUT("/api/users/:id", func(r Request) error {
+		return fmt.Errorf("updating user: %w", &Conflict{httpException{Problem: Problem{Code: "stale_version"}}})
+	})
+	ctx := handleRequest(handler, "PUT", "/api/users/5")
+	assert.Equal(t, 409, ctx.Response.StatusCode())
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(ctx.Response.Body(), &body))
+	assert.Equal(t, "stale_version", body["code"])
+}
+
+func TestErrorHandlerPlainError(t *testing.T) {
+	handler := NewHttpHandler()
+	var reported error
+	handler.OnError(func(r Request, err error) {
+		reported = err
+	})
+	handler.DELETE("/api/users/:id", func(r Request) error {
+		return errors.New("connection refused")
+	})
+	ctx := handleRequest(handler, "DELETE", "/api/users/5")
+	assert.Equal(t, 500, ctx.Response.StatusCode())
+	assert.EqualError(t, reported, "connection refused")
+	assert.NotContains(t, string(ctx.Response.Body()), "connection refused")
+}
+
+func TestErrorsAsHttpError(t *testing.T) {
+	err := fmt.Errorf("loading: %w", NewServiceUnavailable("down"))
+	var target *ServiceUnavailable
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, "down", target.Error())
+}
+
+func TestInvalidHandlerType(t *testing.T) {
+	handler := NewHttpHandler()
+	ex := m3lsh.Try(func() {
+		handler.GET("/api/users", func() {})
+		t.Error("Invalid handler not reported")
+	})
+	assert.IsType(t, &InvalidHandlerException{}, ex)
+}
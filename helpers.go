@@ -1,5 +1,7 @@
 package m3lshttp
 
+import "reflect"
+
 func handlerMapHasKey(mp map[string]handler, key string) bool {
 	if _, ok := mp[key]; ok {
 		return true
@@ -14,3 +16,15 @@ func mapToInterfaceMap(mp map[string][]string) (ret map[string]interface{}) {
 	}
 	return ret
 }
+
+func exceptionMessage(ex interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(ex))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	message := v.FieldByName("Message")
+	if !message.IsValid() || message.Kind() != reflect.String {
+		return ""
+	}
+	return message.String()
+}
@@ -1,5 +1,7 @@
 package m3lshttp
 
+import "github.com/valyala/fasthttp"
+
 func urlNodeArrayContains(arr []*urlNode, node *urlNode) bool {
 	for _, v := range arr {
 		if v == node {
@@ -8,3 +10,11 @@ func urlNodeArrayContains(arr []*urlNode, node *urlNode) bool {
 	}
 	return false
 }
+
+func handleRequest(handler *HttpHandler, method, path string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI(path)
+	ctx.Request.Header.SetMethod(method)
+	handler.handle(ctx)
+	return ctx
+}
@@ -140,3 +140,76 @@ func TestIntegration4(t *testing.T) {
 	}, "/api/sdk/v3/bugs", "", "GET", t)
 	assert.Equal(t, 405, status)
 }
+
+func TestIntegrationStream(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.GET("/api/export", func(r Request) {
+		RespondStream(r, "text/csv", func(w *bufio.Writer) error {
+			for i := 0; i < 3; i++ {
+				fmt.Fprintf(w, "%d,row\n", i)
+				if err := w.Flush(); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	status, body := tempServer(func(ctx *fasthttp.RequestCtx) {
+		handler.handle(ctx)
+	}, "/api/export", "", "GET", t)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "0,row\n1,row\n2,row\n", string(body))
+}
+
+func TestIntegrationStreamError(t *testing.T) {
+	handler := NewHttpHandler()
+	reported := make(chan error, 1)
+	handler.OnError(func(r Request, err error) {
+		reported <- err
+	})
+	handler.GET("/api/export", func(r Request) {
+		RespondStream(r, "text/csv", func(w *bufio.Writer) error {
+			w.WriteString("0,row\n")
+			return fmt.Errorf("database went away")
+		})
+	})
+	status, body := tempServer(func(ctx *fasthttp.RequestCtx) {
+		handler.handle(ctx)
+	}, "/api/export", "", "GET", t)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "0,row\n", string(body))
+	select {
+	case err := <-reported:
+		assert.EqualError(t, err, "database went away")
+	case <-time.After(time.Second):
+		t.Error("Stream error not reported")
+	}
+}
+
+func TestIntegrationStreamPanic(t *testing.T) {
+	handler := NewHttpHandler()
+	reported := make(chan error, 1)
+	handler.OnError(func(r Request, err error) {
+		reported <- err
+	})
+	handler.GET("/api/export", func(r Request) {
+		RespondStream(r, "text/csv", func(w *bufio.Writer) error {
+			w.WriteString("0,row\n")
+			w.Flush()
+			var rows map[string]int
+			rows["1"] = 1
+			return nil
+		})
+	})
+	status, body := tempServer(func(ctx *fasthttp.RequestCtx) {
+		handler.handle(ctx)
+	}, "/api/export", "", "GET", t)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "0,row\n", string(body))
+	select {
+	case err := <-reported:
+		assert.IsType(t, &PanicError{}, err)
+	case <-time.After(time.Second):
+		t.Error("Stream panic not reported")
+	}
+}
@@ -0,0 +1,138 @@
+package m3lshttp
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// the first file descriptor passed by systemd socket activation, see sd_listen_fds(3)
+const systemdFdStart = 3
+
+var ErrNoSystemdListeners = errors.New("m3lshttp: no sockets passed by systemd")
+
+// ListenAndServeUnix removes a stale socket at path before listening and sets mode on the new one;
+// any other kind of file at path is left alone and reported as an error
+func (h *HttpHandler) ListenAndServeUnix(path string, mode os.FileMode) error {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("m3lshttp: %q exists and is not a unix socket", path)
+		}
+		if err = os.Remove(path); err != nil {
+			return fmt.Errorf("m3lshttp: cannot remove unix socket %q: %s", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	ln, err := listenUnix(path, mode)
+	if err != nil {
+		return err
+	}
+	return h.Serve(ln)
+}
+
+// unixListener removes the socket it was renamed to when it is closed
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// listenUnix creates the socket in a private directory and only renames it to path once it has mode,
+// so it is never reachable with the more permissive mode the umask gives it
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".m3lshttp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, filepath.Base(path))
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	ln.SetUnlinkOnClose(false)
+	if err = os.Chmod(tmp, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("m3lshttp: cannot chmod %#o for %q: %s", mode, path, err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return &unixListener{UnixListener: ln, path: path}, nil
+}
+
+// ServeListeners serves on every listener; when one of them fails the others are closed
+// and its error is returned straight away
+func (h *HttpHandler) ServeListeners(listeners ...net.Listener) error {
+	errs := make(chan error, len(listeners))
+	for _, ln := range listeners {
+		go func(ln net.Listener) {
+			errs <- h.Serve(ln)
+		}(ln)
+	}
+	for range listeners {
+		if err := <-errs; err != nil {
+			for _, ln := range listeners {
+				ln.Close()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *HttpHandler) ServeSystemd() error {
+	listeners, err := SystemdListeners()
+	if err != nil {
+		return err
+	}
+	return h.ServeListeners(listeners...)
+}
+
+// SystemdListeners returns the sockets passed by systemd socket activation and unsets the
+// LISTEN_* variables so child processes don't pick them up again
+func SystemdListeners() ([]net.Listener, error) {
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+	defer os.Unsetenv("LISTEN_FDNAMES")
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, ErrNoSystemdListeners
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, ErrNoSystemdListeners
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	listeners := make([]net.Listener, 0, count)
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("LISTEN_FD_%d", systemdFdStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(systemdFdStart+i), name)
+		ln, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("m3lshttp: systemd socket %s is not a listener: %s", name, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
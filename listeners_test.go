@@ -0,0 +1,128 @@
+package m3lshttp
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestListenAndServeUnix(t *testing.T) {
+	defer func(grace time.Duration) { ShutdownIdleGrace = grace }(ShutdownIdleGrace)
+	ShutdownIdleGrace = 50 * time.Millisecond
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "m3lsh.sock")
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	handler := NewHttpHandler()
+	handler.GET("/", func(r Request) {})
+	served := make(chan error, 1)
+	go func() { served <- handler.ListenAndServeUnix(path, 0660) }()
+
+	var c net.Conn
+	assert.Eventually(t, func() bool {
+		var err error
+		c, err = net.Dial("unix", path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	require.NotNil(t, c)
+	defer c.Close()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0660), info.Mode().Perm())
+	assert.Equal(t, 200, sendRequest(t, c, "GET", "/", ""))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, handler.Shutdown(ctx))
+	assert.NoError(t, <-served)
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestListenAndServeUnixKeepsRegularFiles(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("keep me"), 0600))
+
+	assert.Error(t, NewHttpHandler().ListenAndServeUnix(path, 0660))
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "keep me", string(data))
+}
+
+func TestServeListeners(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.GET("/", func(r Request) {})
+	first, second := fasthttputil.NewInmemoryListener(), fasthttputil.NewInmemoryListener()
+	served := make(chan error, 1)
+	go func() { served <- handler.ServeListeners(first, second) }()
+
+	for _, ln := range []*fasthttputil.InmemoryListener{first, second} {
+		c, err := ln.Dial()
+		require.NoError(t, err)
+		assert.Equal(t, 200, sendRequest(t, c, "GET", "/", ""))
+		c.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, handler.Shutdown(ctx))
+	assert.NoError(t, <-served)
+}
+
+type brokenListener struct {
+	net.Listener
+}
+
+func (brokenListener) Accept() (net.Conn, error) {
+	return nil, errors.New("socket is gone")
+}
+
+func (brokenListener) Close() error {
+	return nil
+}
+
+func TestServeListenersFailsFast(t *testing.T) {
+	handler := NewHttpHandler()
+	healthy := fasthttputil.NewInmemoryListener()
+	served := make(chan error, 1)
+	go func() { served <- handler.ServeListeners(healthy, brokenListener{}) }()
+
+	select {
+	case err := <-served:
+		assert.EqualError(t, err, "socket is gone")
+	case <-time.After(time.Second):
+		t.Fatal("ServeListeners did not return the failing listener's error")
+	}
+	_, err := healthy.Dial()
+	assert.Error(t, err)
+}
+
+func TestSystemdListenersNotActivated(t *testing.T) {
+	os.Unsetenv("LISTEN_PID")
+	_, err := SystemdListeners()
+	assert.Equal(t, ErrNoSystemdListeners, err)
+
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	os.Setenv("LISTEN_FDS", "1")
+	_, err = SystemdListeners()
+	assert.Equal(t, ErrNoSystemdListeners, err)
+	assert.Empty(t, os.Getenv("LISTEN_FDS"))
+	assert.Equal(t, ErrNoSystemdListeners, NewHttpHandler().ServeSystemd())
+}
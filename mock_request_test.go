@@ -2,6 +2,9 @@
 package m3lshttp
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+
 	mock "github.com/stretchr/testify/mock"
 	"github.com/valyala/fasthttp"
 )
@@ -21,6 +24,16 @@ func (_m *RequestMock) pushPathParam(name string, value string) {
 	_m.Called(name, value)
 }
 
+// reportError provides a mock function with given fields: err
+func (_m *RequestMock) reportError(err error) {
+	_m.Called(err)
+}
+
+// setDecodeOptions provides a mock function with given fields: options
+func (_m *RequestMock) setDecodeOptions(options *DecodeOptions) {
+	_m.Called(options)
+}
+
 // Body provides a mock function with given fields:
 func (_m *RequestMock) Body() []byte {
 	ret := _m.Called()
@@ -65,6 +78,34 @@ func (_m *RequestMock) Method() string {
 	return r0
 }
 
+// TLS provides a mock function with given fields:
+func (_m *RequestMock) TLS() *tls.ConnectionState {
+	ret := _m.Called()
+
+	var r0 *tls.ConnectionState
+	if rf, ok := ret.Get(0).(func() *tls.ConnectionState); ok {
+		r0 = rf()
+	} else if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*tls.ConnectionState)
+	}
+
+	return r0
+}
+
+// PeerCertificate provides a mock function with given fields:
+func (_m *RequestMock) PeerCertificate() *x509.Certificate {
+	ret := _m.Called()
+
+	var r0 *x509.Certificate
+	if rf, ok := ret.Get(0).(func() *x509.Certificate); ok {
+		r0 = rf()
+	} else if ret.Get(0) != nil {
+		r0 = ret.Get(0).(*x509.Certificate)
+	}
+
+	return r0
+}
+
 // MultipartForm provides a mock function with given fields:
 func (_m *RequestMock) MultipartForm() map[string][]string {
 	ret := _m.Called()
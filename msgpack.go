@@ -0,0 +1,286 @@
+package m3lshttp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+func marshalMsgPack(data interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := writeMsgPack(buf, reflect.ValueOf(data)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeMsgPack(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+	if v.Type() == jsonNumberType {
+		return writeMsgPackNumber(buf, json.Number(v.String()))
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return writeMsgPack(buf, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeMsgPackInt(buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeMsgPackUint(buf, v.Uint())
+	case reflect.Float32:
+		buf.WriteByte(0xca)
+		binary.Write(buf, binary.BigEndian, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		buf.WriteByte(0xcb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(v.Float()))
+	case reflect.String:
+		writeMsgPackString(buf, v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			writeMsgPackBinary(buf, v.Bytes())
+			return nil
+		}
+		return writeMsgPackArray(buf, v)
+	case reflect.Array:
+		return writeMsgPackArray(buf, v)
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return writeMsgPackMap(buf, v)
+	case reflect.Struct:
+		return writeMsgPackStruct(buf, v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func writeMsgPackNumber(buf *bytes.Buffer, n json.Number) error {
+	if i, err := n.Int64(); err == nil {
+		writeMsgPackInt(buf, i)
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	return writeMsgPack(buf, reflect.ValueOf(f))
+}
+
+func writeMsgPackInt(buf *bytes.Buffer, n int64) {
+	switch {
+	case n >= 0:
+		writeMsgPackUint(buf, uint64(n))
+	case n >= -32:
+		buf.WriteByte(byte(n))
+	case n >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(n))
+	case n >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		binary.Write(buf, binary.BigEndian, int16(n))
+	case n >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		binary.Write(buf, binary.BigEndian, int32(n))
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+func writeMsgPackUint(buf *bytes.Buffer, n uint64) {
+	switch {
+	case n <= 0x7f:
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(0xcf)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+func writeMsgPackString(buf *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n <= 31:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	buf.WriteString(s)
+}
+
+func writeMsgPackBinary(buf *bytes.Buffer, b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xc4)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xc5)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xc6)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	buf.Write(b)
+}
+
+func writeMsgPackArrayHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n <= 15:
+		buf.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xdc)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdd)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+func writeMsgPackMapHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n <= 15:
+		buf.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xde)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdf)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+func writeMsgPackArray(buf *bytes.Buffer, v reflect.Value) error {
+	writeMsgPackArrayHeader(buf, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if err := writeMsgPack(buf, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMsgPackMap(buf *bytes.Buffer, v reflect.Value) error {
+	keys := v.MapKeys()
+	// sorted so the output is deterministic
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	writeMsgPackMapHeader(buf, len(keys))
+	for _, k := range keys {
+		if err := writeMsgPack(buf, k); err != nil {
+			return err
+		}
+		if err := writeMsgPack(buf, v.MapIndex(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMsgPackStruct(buf *bytes.Buffer, v reflect.Value) error {
+	t := v.Type()
+	names := make([]string, 0, t.NumField())
+	values := make([]reflect.Value, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, omitEmpty, skip := msgPackFieldName(field)
+		if skip || (omitEmpty && isEmptyValue(v.Field(i))) {
+			continue
+		}
+		names = append(names, name)
+		values = append(values, v.Field(i))
+	}
+	writeMsgPackMapHeader(buf, len(names))
+	for i, name := range names {
+		writeMsgPackString(buf, name)
+		if err := writeMsgPack(buf, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func msgPackFieldName(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag, ok := field.Tag.Lookup("msgpack")
+	if !ok {
+		tag = field.Tag.Get("json")
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
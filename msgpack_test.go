@@ -0,0 +1,76 @@
+package m3lshttp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestMsgPackScalars(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{false, []byte{0xc2}},
+		{5, []byte{0x05}},
+		{-1, []byte{0xff}},
+		{-100, []byte{0xd0, 0x9c}},
+		{200, []byte{0xcc, 0xc8}},
+		{1000, []byte{0xcd, 0x03, 0xe8}},
+		{70000, []byte{0xce, 0x00, 0x01, 0x11, 0x70}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{float32(1.5), []byte{0xca, 0x3f, 0xc0, 0, 0}},
+		{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{[]byte{1, 2}, []byte{0xc4, 0x02, 0x01, 0x02}},
+		{[]int{1, 2}, []byte{0x92, 0x01, 0x02}},
+	}
+	for _, c := range cases {
+		out, err := marshalMsgPack(c.value)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, out, "%#v", c.value)
+	}
+}
+
+func TestMsgPackStruct(t *testing.T) {
+	type user struct {
+		Name    string `json:"name"`
+		Age     int    `msgpack:"age"`
+		Ignored string `json:"-"`
+		Empty   string `json:"empty,omitempty"`
+		private string
+	}
+	out, err := marshalMsgPack(&user{Name: "a", Age: 3, Ignored: "x", private: "y"})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x82, 0xa4, 'n', 'a', 'm', 'e', 0xa1, 'a', 0xa3, 'a', 'g', 'e', 0x03}, out)
+}
+
+func TestMsgPackUnsupported(t *testing.T) {
+	_, err := marshalMsgPack(make(chan int))
+	assert.Error(t, err)
+}
+
+func TestMsgPackJsonNumber(t *testing.T) {
+	out, err := marshalMsgPack([]interface{}{json.Number("3"), json.Number("1.5")})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x92, 0x03, 0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}, out)
+}
+
+func TestMsgPackRespondParsedBody(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.POST("/api/echo", func(r Request) {
+		Respond(r, MsgPack, r.Params().Unwrap())
+	})
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/api/echo")
+	ctx.Request.Header.SetMethod("POST")
+	ctx.Request.Header.SetContentType("application/json")
+	ctx.Request.SetBodyString(`{"n": 3, "f": 1.5}`)
+	handler.handle(ctx)
+	require.Equal(t, 200, ctx.Response.StatusCode())
+	assert.Equal(t, []byte{0x82, 0xa1, 'f', 0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0, 0xa1, 'n', 0x03}, ctx.Response.Body())
+}
@@ -0,0 +1,139 @@
+package m3lshttp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mohamed-essam/m3lsh"
+)
+
+type mediaRange struct {
+	mainType string
+	subType  string
+	quality  float64
+}
+
+// NegotiableEncoder is implemented by encoders that suit only some data, Negotiate skips them for the rest
+type NegotiableEncoder interface {
+	Encoder
+	Negotiable(data interface{}) bool
+}
+
+type negotiated struct {
+	encoder Encoder
+	quality float64
+}
+
+// Negotiate sets Vary: Accept on every outcome so caches key the response on the Accept header.
+// When the best encoder fails the next one is tried, 406 is thrown when none is left
+func Negotiate(r Request, data interface{}) {
+	r.context().Response.Header.Add("Vary", "Accept")
+	accept := string(r.context().Request.Header.Peek("Accept"))
+	for _, encoder := range negotiateEncoders(parseAccept(accept), data) {
+		if body, err := encoder.Encode(data); err == nil {
+			r.context().Response.Header.SetContentType(encoder.ContentType())
+			r.context().Response.SetBody(body)
+			return
+		}
+	}
+	m3lsh.Throw(&NotAcceptable{}, "None of the accepted media types can be produced")
+}
+
+// negotiateEncoders returns the acceptable encoders for data, highest quality first
+func negotiateEncoders(ranges []mediaRange, data interface{}) []Encoder {
+	candidates := make([]negotiated, 0)
+	for _, encoder := range registeredEncoders() {
+		if n, ok := encoder.(NegotiableEncoder); ok && !n.Negotiable(data) {
+			continue
+		}
+		if quality := acceptQuality(ranges, encoder.ContentType()); quality > 0 {
+			candidates = append(candidates, negotiated{encoder: encoder, quality: quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	ret := make([]Encoder, len(candidates))
+	for i, candidate := range candidates {
+		ret[i] = candidate.encoder
+	}
+	return ret
+}
+
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+	if strings.TrimSpace(accept) == "" {
+		return append(ranges, mediaRange{mainType: "*", subType: "*", quality: 1})
+	}
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mainType, subType := splitMediaType(params[0])
+		if mainType == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || strings.ToLower(strings.TrimSpace(kv[0])) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+			if err != nil || q < 0 || q > 1 {
+				q = 0
+			}
+			quality = q
+		}
+		ranges = append(ranges, mediaRange{mainType: mainType, subType: subType, quality: quality})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific range matching contentType
+func acceptQuality(ranges []mediaRange, contentType string) float64 {
+	mainType, subType := splitMediaType(strings.Split(contentType, ";")[0])
+	quality := 0.0
+	specificity := -1
+	for _, rng := range ranges {
+		s := rng.specificity(mainType, subType)
+		if s > specificity {
+			specificity, quality = s, rng.quality
+		}
+	}
+	return quality
+}
+
+func (m mediaRange) specificity(mainType, subType string) int {
+	switch {
+	case m.mainType == mainType && m.subType == subType:
+		return 2
+	case m.mainType == mainType && m.subType == "*":
+		return 1
+	case m.mainType == "*" && m.subType == "*":
+		return 0
+	}
+	return -1
+}
+
+func splitMediaType(mediaType string) (string, string) {
+	parts := strings.SplitN(strings.ToLower(strings.TrimSpace(mediaType)), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+func registeredEncoders() []Encoder {
+	encodersLock.RLock()
+	defer encodersLock.RUnlock()
+	types := make([]int, 0, len(encoders))
+	for responseType := range encoders {
+		types = append(types, int(responseType))
+	}
+	sort.Ints(types)
+	ret := make([]Encoder, 0, len(types))
+	for _, responseType := range types {
+		ret = append(ret, encoders[ResponseType(responseType)])
+	}
+	return ret
+}
@@ -0,0 +1,105 @@
+package m3lshttp
+
+import (
+	"testing"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func negotiateWith(accept string, data interface{}) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	if accept != "" {
+		ctx.Request.Header.Set("Accept", accept)
+	}
+	req := &RequestMock{}
+	req.On("context").Return(ctx)
+	Negotiate(req, data)
+	return ctx
+}
+
+func TestParseAccept(t *testing.T) {
+	ranges := parseAccept("text/html, application/xml;q=0.9, */*;q=0.1, bogus")
+	require.Len(t, ranges, 3)
+	assert.Equal(t, mediaRange{mainType: "text", subType: "html", quality: 1}, ranges[0])
+	assert.Equal(t, mediaRange{mainType: "application", subType: "xml", quality: 0.9}, ranges[1])
+	assert.Equal(t, mediaRange{mainType: "*", subType: "*", quality: 0.1}, ranges[2])
+}
+
+func TestParseAcceptEmpty(t *testing.T) {
+	ranges := parseAccept("")
+	require.Len(t, ranges, 1)
+	assert.Equal(t, mediaRange{mainType: "*", subType: "*", quality: 1}, ranges[0])
+}
+
+func TestAcceptQualityPrefersSpecificRange(t *testing.T) {
+	ranges := parseAccept("application/*;q=0.5, application/json;q=0, */*")
+	assert.Equal(t, 0.0, acceptQuality(ranges, "application/json"))
+	assert.Equal(t, 0.5, acceptQuality(ranges, "application/xml"))
+	assert.Equal(t, 1.0, acceptQuality(ranges, "text/plain; charset=utf-8"))
+}
+
+func TestNegotiateDefaultsToJson(t *testing.T) {
+	ctx := negotiateWith("", map[string]string{"a": "b"})
+	assert.Equal(t, "application/json", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, "Accept", string(ctx.Response.Header.Peek("Vary")))
+}
+
+func TestNegotiateHighestQuality(t *testing.T) {
+	ctx := negotiateWith("application/json;q=0.5, application/msgpack", map[string]int{"a": 1})
+	assert.Equal(t, "application/msgpack", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, []byte{0x81, 0xa1, 'a', 0x01}, ctx.Response.Body())
+}
+
+func TestNegotiateWildcardSubtype(t *testing.T) {
+	ctx := negotiateWith("text/*", "7amada")
+	assert.Equal(t, "text/plain; charset=utf-8", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, "7amada", string(ctx.Response.Body()))
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	ex := m3lsh.Try(func() {
+		negotiateWith("image/png", "7amada")
+		t.Error("Not acceptable not reported")
+	})
+	require.NotNil(t, ex)
+	assert.IsType(t, &NotAcceptable{}, ex)
+}
+
+func TestNegotiateNotAcceptableStatus(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.GET("/api/version", func(r Request) {
+		Negotiate(r, map[string]string{"version": "1.0"})
+	})
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/api/version")
+	ctx.Request.Header.SetMethod("GET")
+	ctx.Request.Header.Set("Accept", "image/png")
+	handler.handle(ctx)
+	assert.Equal(t, 406, ctx.Response.StatusCode())
+	assert.Equal(t, "Accept", string(ctx.Response.Header.Peek("Vary")))
+}
+
+func TestNegotiateSkipsTextForStructuredData(t *testing.T) {
+	ctx := negotiateWith("text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", map[string]string{"a": "<b>"})
+	assert.Equal(t, "application/json", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, `{"a":"\u003cb\u003e"}`, string(ctx.Response.Body()))
+}
+
+func TestNegotiateFallsBackWhenEncodingFails(t *testing.T) {
+	ctx := negotiateWith("application/xml, application/json;q=0.5", map[string]int{"a": 1})
+	assert.Equal(t, "application/json", string(ctx.Response.Header.ContentType()))
+}
+
+func TestNegotiateRawRejectsStructuredData(t *testing.T) {
+	ex := m3lsh.Try(func() {
+		negotiateWith("application/octet-stream", map[string]int{"a": 1})
+		t.Error("Not acceptable not reported")
+	})
+	assert.IsType(t, &NotAcceptable{}, ex)
+
+	ctx := negotiateWith("application/octet-stream", []byte{1, 2})
+	assert.Equal(t, []byte{1, 2}, ctx.Response.Body())
+}
@@ -1,8 +1,12 @@
 package m3lshttp
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/mohamed-essam/m3lsh"
 )
@@ -21,11 +25,82 @@ type Params interface {
 	AsLong() int64
 	AsFloat() float32
 	AsDouble() float64
+	Bool() bool
+	AsBool() bool
+	Time() time.Time
+	AsTime(layout string) time.Time
+	Duration() time.Duration
+	UUID() UUID
+	Bytes() []byte
+	Bind(dst interface{})
+	Validate(rules Rules)
+	Has(key string) bool
+	IsNull() bool
+	Kind() Kind
+	TryObject(name string) (Params, bool)
+	TryString() (string, bool)
+	StringOr(def string) string
+	TryInteger() (int, bool)
+	IntegerOr(def int) int
+	TryAsInteger() (int, bool)
+	AsIntegerOr(def int) int
+	TryLong() (int64, bool)
+	LongOr(def int64) int64
+	TryAsLong() (int64, bool)
+	AsLongOr(def int64) int64
+	TryFloat() (float32, bool)
+	FloatOr(def float32) float32
+	TryAsFloat() (float32, bool)
+	AsFloatOr(def float32) float32
+	TryDouble() (float64, bool)
+	DoubleOr(def float64) float64
+	TryAsDouble() (float64, bool)
+	AsDoubleOr(def float64) float64
+	Len() int
+	Each(fn func(i int, p Params))
+	Keys() []string
+	StringSlice() []string
+	IntSlice() []int
+	DoubleSlice() []float64
+	Get(path string) Params
+	TryGet(path string) (Params, bool)
+	Set(path string, value interface{})
+	Delete(path string)
+	Unwrap() interface{}
+	ToMap() map[string]interface{}
+	Clone() Params
 	addObject(key, value string)
+	bindInput(dst interface{})
 }
 
+type Kind int
+
+const (
+	NullKind Kind = iota
+	StringKind
+	NumberKind
+	BoolKind
+	ObjectKind
+	ArrayKind
+	UnknownKind
+)
+
+const (
+	maxInt  = int64(^uint(0) >> 1)
+	minInt  = -maxInt - 1
+	twoTo63 = 1 << 63
+)
+
 type ParamsWrapper struct {
-	object interface{}
+	object  interface{}
+	options *DecodeOptions
+	// root is set on the _data envelope built by cleanObject
+	root bool
+	// parent and segments locate a wrapper returned by GetObject, GetArray, Get or Each,
+	// so Set and Delete can write a replaced object back into the tree
+	parent   *ParamsWrapper
+	segments []pathSegment
+	path     string
 }
 
 type InvalidTypeException struct {
@@ -33,32 +108,47 @@ type InvalidTypeException struct {
 	Object interface{}
 }
 
+type IndexOutOfRangeException struct {
+	m3lsh.BaseException
+	Index  int
+	Length int
+}
+
+const dataKey = "_data"
+
 func newParams(object interface{}) Params {
-	return &ParamsWrapper{object: cleanObject(object)}
+	return &ParamsWrapper{object: cleanObject(object), root: true}
 }
 
 func cleanObject(object interface{}) interface{} {
-	return map[string]interface{}{"_data": object}
+	return map[string]interface{}{dataKey: object}
+}
+
+func (p *ParamsWrapper) wrap(object interface{}, path string, segments ...pathSegment) Params {
+	return &ParamsWrapper{object: object, options: p.options, parent: p, segments: segments, path: path}
 }
 
 func (p ParamsWrapper) Object() interface{} {
 	return p.object
 }
 
-func (p ParamsWrapper) GetObject(name string) Params {
+func (p *ParamsWrapper) GetObject(name string) Params {
 	mp, ok := p.object.(map[string]interface{})
 	if !ok {
 		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not a map")
 	}
-	return &ParamsWrapper{object: mp[name]}
+	return p.wrap(mp[name], name, pathSegment{token: name})
 }
 
-func (p ParamsWrapper) GetArray(idx int) Params {
+func (p *ParamsWrapper) GetArray(idx int) Params {
 	ar, ok := p.object.([]interface{})
 	if !ok {
 		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not an array")
 	}
-	return &ParamsWrapper{object: ar[idx]}
+	if idx < 0 || idx >= len(ar) {
+		m3lsh.Throw(&IndexOutOfRangeException{Index: idx, Length: len(ar)}, fmt.Sprintf("Index %d out of range for array of length %d", idx, len(ar)))
+	}
+	return p.wrap(ar[idx], fmt.Sprintf("[%d]", idx), indexSegment(idx))
 }
 
 func (p ParamsWrapper) StringValue() string {
@@ -74,49 +164,69 @@ func (p ParamsWrapper) AsString() string {
 }
 
 func (p ParamsWrapper) Integer() int {
-	num, ok := p.object.(int)
-	if !ok {
-		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not an int")
+	switch v := p.object.(type) {
+	case int:
+		return v
+	case json.Number:
+		if num, err := strconv.ParseInt(string(v), 10, strconv.IntSize); err == nil {
+			return int(num)
+		}
 	}
-	return num
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not an int")
+	return 0
 }
 
 func (p ParamsWrapper) Long() int64 {
-	num, ok := p.object.(int64)
-	if !ok {
-		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not an int64")
+	switch v := p.object.(type) {
+	case int64:
+		return v
+	case json.Number:
+		if num, err := v.Int64(); err == nil {
+			return num
+		}
 	}
-	return num
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not an int64")
+	return 0
 }
 
 func (p ParamsWrapper) Float() float32 {
-	num, ok := p.object.(float32)
-	if !ok {
-		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not a float32")
+	switch v := p.object.(type) {
+	case float32:
+		return v
+	case json.Number:
+		if num, err := strconv.ParseFloat(string(v), 32); err == nil {
+			return float32(num)
+		}
 	}
-	return num
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not a float32")
+	return 0
 }
 
 func (p ParamsWrapper) Double() float64 {
-	num, ok := p.object.(float64)
-	if !ok {
-		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not a float64")
+	switch v := p.object.(type) {
+	case float64:
+		return v
+	case json.Number:
+		if num, err := v.Float64(); err == nil {
+			return num
+		}
 	}
-	return num
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not a float64")
+	return 0
 }
 
 func (p ParamsWrapper) AsInteger() int {
 	switch p.object.(type) {
 	case int:
 		return p.Integer()
-	case int64:
-		return int(p.Long())
-	case float32:
-		return int(p.Float())
-	case float64:
-		return int(p.Double())
+	case int64, float32, float64, json.Number:
+		num := p.AsLong()
+		if num > maxInt || num < minInt {
+			m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object overflows int")
+		}
+		return int(num)
 	case string:
-		conv, err := strconv.ParseInt(p.StringValue(), 10, 32)
+		conv, err := strconv.ParseInt(p.StringValue(), 10, strconv.IntSize)
 		if err != nil {
 			m3lsh.Throw(&InvalidTypeException{Object: p.object}, "String cannot be converted to int")
 		}
@@ -127,15 +237,24 @@ func (p ParamsWrapper) AsInteger() int {
 }
 
 func (p ParamsWrapper) AsLong() int64 {
-	switch p.object.(type) {
+	switch v := p.object.(type) {
 	case int:
 		return int64(p.Integer())
 	case int64:
 		return p.Long()
 	case float32:
-		return int64(p.Float())
+		return p.floatToLong(float64(p.Float()))
 	case float64:
-		return int64(p.Double())
+		return p.floatToLong(p.Double())
+	case json.Number:
+		if num, err := v.Int64(); err == nil {
+			return num
+		}
+		num, err := v.Float64()
+		if err != nil {
+			m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Number cannot be converted to int64")
+		}
+		return p.floatToLong(num)
 	case string:
 		conv, err := strconv.ParseInt(p.StringValue(), 10, 64)
 		if err != nil {
@@ -148,7 +267,7 @@ func (p ParamsWrapper) AsLong() int64 {
 }
 
 func (p ParamsWrapper) AsFloat() float32 {
-	switch p.object.(type) {
+	switch v := p.object.(type) {
 	case int:
 		return float32(p.Integer())
 	case int64:
@@ -156,15 +275,20 @@ func (p ParamsWrapper) AsFloat() float32 {
 	case float32:
 		return p.Float()
 	case float64:
+		if math.Abs(v) > math.MaxFloat32 && !math.IsInf(v, 0) {
+			m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object overflows float32")
+		}
 		return float32(p.Double())
+	case json.Number:
+		return p.Float()
 	case string:
-		conv, err := strconv.ParseFloat(p.StringValue(), 64)
+		conv, err := strconv.ParseFloat(p.StringValue(), 32)
 		if err != nil {
-			m3lsh.Throw(&InvalidTypeException{Object: p.object}, "String cannot be converted to int64")
+			m3lsh.Throw(&InvalidTypeException{Object: p.object}, "String cannot be converted to float32")
 		}
 		return float32(conv)
 	}
-	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object cannot be converted to int64")
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object cannot be converted to float32")
 	return 0
 }
 
@@ -176,19 +300,26 @@ func (p ParamsWrapper) AsDouble() float64 {
 		return float64(p.Long())
 	case float32:
 		return float64(p.Float())
-	case float64:
+	case float64, json.Number:
 		return p.Double()
 	case string:
 		conv, err := strconv.ParseFloat(p.StringValue(), 64)
 		if err != nil {
-			m3lsh.Throw(&InvalidTypeException{Object: p.object}, "String cannot be converted to int64")
+			m3lsh.Throw(&InvalidTypeException{Object: p.object}, "String cannot be converted to float64")
 		}
-		return float64(conv)
+		return conv
 	}
-	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object cannot be converted to int64")
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object cannot be converted to float64")
 	return 0
 }
 
+func (p ParamsWrapper) floatToLong(num float64) int64 {
+	if math.IsNaN(num) || num < -twoTo63 || num >= twoTo63 {
+		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object overflows int64")
+	}
+	return int64(num)
+}
+
 func (p *ParamsWrapper) addObject(key, value string) {
 	mp, ok := p.object.(map[string]interface{})
 	if !ok {
@@ -196,3 +327,221 @@ func (p *ParamsWrapper) addObject(key, value string) {
 	}
 	mp[key] = value
 }
+
+func (p ParamsWrapper) Has(key string) bool {
+	mp, ok := p.object.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = mp[key]
+	return ok
+}
+
+func (p ParamsWrapper) IsNull() bool {
+	return p.object == nil
+}
+
+func (p ParamsWrapper) Kind() Kind {
+	switch p.object.(type) {
+	case nil:
+		return NullKind
+	case string:
+		return StringKind
+	case int, int64, float32, float64, json.Number:
+		return NumberKind
+	case bool:
+		return BoolKind
+	case map[string]interface{}:
+		return ObjectKind
+	case []interface{}:
+		return ArrayKind
+	}
+	return UnknownKind
+}
+
+func (p *ParamsWrapper) TryObject(name string) (Params, bool) {
+	if !p.Has(name) {
+		return nil, false
+	}
+	return p.GetObject(name), true
+}
+
+func (p ParamsWrapper) TryString() (string, bool) {
+	str, ok := p.object.(string)
+	return str, ok
+}
+
+func (p ParamsWrapper) StringOr(def string) string {
+	if str, ok := p.TryString(); ok {
+		return str
+	}
+	return def
+}
+
+// the numeric Try* and *Or accessors are as strict as Integer, Long, Float and Double;
+// TryAs* and As*Or use the conversions of the As* accessors
+
+func (p ParamsWrapper) TryInteger() (ret int, ok bool) {
+	ok = tryConvert(func() { ret = p.Integer() })
+	return ret, ok
+}
+
+func (p ParamsWrapper) IntegerOr(def int) int {
+	if num, ok := p.TryInteger(); ok {
+		return num
+	}
+	return def
+}
+
+func (p ParamsWrapper) TryAsInteger() (ret int, ok bool) {
+	ok = tryConvert(func() { ret = p.AsInteger() })
+	return ret, ok
+}
+
+func (p ParamsWrapper) AsIntegerOr(def int) int {
+	if num, ok := p.TryAsInteger(); ok {
+		return num
+	}
+	return def
+}
+
+func (p ParamsWrapper) TryLong() (ret int64, ok bool) {
+	ok = tryConvert(func() { ret = p.Long() })
+	return ret, ok
+}
+
+func (p ParamsWrapper) LongOr(def int64) int64 {
+	if num, ok := p.TryLong(); ok {
+		return num
+	}
+	return def
+}
+
+func (p ParamsWrapper) TryAsLong() (ret int64, ok bool) {
+	ok = tryConvert(func() { ret = p.AsLong() })
+	return ret, ok
+}
+
+func (p ParamsWrapper) AsLongOr(def int64) int64 {
+	if num, ok := p.TryAsLong(); ok {
+		return num
+	}
+	return def
+}
+
+func (p ParamsWrapper) TryFloat() (ret float32, ok bool) {
+	ok = tryConvert(func() { ret = p.Float() })
+	return ret, ok
+}
+
+func (p ParamsWrapper) FloatOr(def float32) float32 {
+	if num, ok := p.TryFloat(); ok {
+		return num
+	}
+	return def
+}
+
+func (p ParamsWrapper) TryAsFloat() (ret float32, ok bool) {
+	ok = tryConvert(func() { ret = p.AsFloat() })
+	return ret, ok
+}
+
+func (p ParamsWrapper) AsFloatOr(def float32) float32 {
+	if num, ok := p.TryAsFloat(); ok {
+		return num
+	}
+	return def
+}
+
+func (p ParamsWrapper) TryDouble() (ret float64, ok bool) {
+	ok = tryConvert(func() { ret = p.Double() })
+	return ret, ok
+}
+
+func (p ParamsWrapper) DoubleOr(def float64) float64 {
+	if num, ok := p.TryDouble(); ok {
+		return num
+	}
+	return def
+}
+
+func (p ParamsWrapper) TryAsDouble() (ret float64, ok bool) {
+	ok = tryConvert(func() { ret = p.AsDouble() })
+	return ret, ok
+}
+
+func (p ParamsWrapper) AsDoubleOr(def float64) float64 {
+	if num, ok := p.TryAsDouble(); ok {
+		return num
+	}
+	return def
+}
+
+func tryConvert(fn func()) bool {
+	ex := m3lsh.Try(fn)
+	if ex == nil {
+		return true
+	}
+	if _, ok := ex.(*InvalidTypeException); !ok {
+		panic(ex)
+	}
+	return false
+}
+
+func (p ParamsWrapper) Len() int {
+	switch v := p.object.(type) {
+	case []interface{}:
+		return len(v)
+	case map[string]interface{}:
+		return len(v)
+	}
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not an array or a map")
+	return 0
+}
+
+func (p *ParamsWrapper) Each(fn func(i int, p Params)) {
+	ar, ok := p.object.([]interface{})
+	if !ok {
+		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not an array")
+	}
+	for i, v := range ar {
+		fn(i, p.wrap(v, fmt.Sprintf("[%d]", i), indexSegment(i)))
+	}
+}
+
+func (p ParamsWrapper) Keys() []string {
+	mp, ok := p.object.(map[string]interface{})
+	if !ok {
+		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not a map")
+	}
+	keys := make([]string, 0, len(mp))
+	for k := range mp {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (p ParamsWrapper) StringSlice() []string {
+	ret := make([]string, 0)
+	p.Each(func(i int, item Params) {
+		ret = append(ret, item.StringValue())
+	})
+	return ret
+}
+
+func (p ParamsWrapper) IntSlice() []int {
+	ret := make([]int, 0)
+	p.Each(func(i int, item Params) {
+		ret = append(ret, item.AsInteger())
+	})
+	return ret
+}
+
+func (p ParamsWrapper) DoubleSlice() []float64 {
+	ret := make([]float64, 0)
+	p.Each(func(i int, item Params) {
+		ret = append(ret, item.AsDouble())
+	})
+	return ret
+}
@@ -0,0 +1,76 @@
+package m3lshttp
+
+import (
+	"encoding/json"
+
+	"github.com/mohamed-essam/m3lsh"
+)
+
+// Unwrap returns the decoded body without the _data envelope added by cleanObject
+func (p ParamsWrapper) Unwrap() interface{} {
+	if mp, ok := p.object.(map[string]interface{}); ok && p.root {
+		return mp[dataKey]
+	}
+	return p.object
+}
+
+// ToMap merges the path params with the body's fields; path params win on conflicts.
+// A body that is not an object cannot be merged and throws InvalidTypeException
+func (p ParamsWrapper) ToMap() map[string]interface{} {
+	mp, ok := p.object.(map[string]interface{})
+	if !ok {
+		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not a map")
+	}
+	if p.root && !mergeableBody(mp[dataKey]) {
+		m3lsh.Throw(&InvalidTypeException{Object: mp[dataKey]}, "Body is not an object")
+	}
+	ret := make(map[string]interface{}, len(mp))
+	if body, ok := mp[dataKey].(map[string]interface{}); ok && p.root {
+		for k, v := range body {
+			ret[k] = v
+		}
+	}
+	for k, v := range mp {
+		if k != dataKey || !p.root {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// MarshalJSON writes the root like ToMap when the body can be merged, and the body alone otherwise
+func (p ParamsWrapper) MarshalJSON() ([]byte, error) {
+	if mp, ok := p.object.(map[string]interface{}); ok && p.root && mergeableBody(mp[dataKey]) {
+		return json.Marshal(p.ToMap())
+	}
+	return json.Marshal(p.Unwrap())
+}
+
+func mergeableBody(body interface{}) bool {
+	_, ok := body.(map[string]interface{})
+	return ok || body == nil
+}
+
+func (p ParamsWrapper) Clone() Params {
+	return &ParamsWrapper{object: deepCopy(p.object), options: p.options, root: p.root}
+}
+
+func deepCopy(object interface{}) interface{} {
+	switch v := object.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			ret[k] = deepCopy(item)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, item := range v {
+			ret[i] = deepCopy(item)
+		}
+		return ret
+	case []byte:
+		return append([]byte(nil), v...)
+	}
+	return object
+}
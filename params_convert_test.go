@@ -0,0 +1,68 @@
+package m3lshttp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envelopedParams() Params {
+	p := newParams(map[string]interface{}{"name": "a", "id": "body", "tags": []interface{}{"x"}})
+	p.addObject("id", "7")
+	return p
+}
+
+func TestUnwrap(t *testing.T) {
+	assert.Equal(t, []interface{}{"x"}, newParams([]interface{}{"x"}).Unwrap())
+	assert.Equal(t, "b", newParams(map[string]interface{}{"a": "b"}).GetObject(dataKey).GetObject("a").Unwrap())
+}
+
+func TestToMap(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{"name": "a", "id": "7", "tags": []interface{}{"x"}}, envelopedParams().ToMap())
+	assert.Equal(t, map[string]interface{}{}, newParams(nil).ToMap())
+	assertInvalidType(t, func() { newParams("abc").GetObject(dataKey).ToMap() })
+}
+
+func TestToMapNonObjectBody(t *testing.T) {
+	p := newParams([]interface{}{"x"})
+	p.addObject("id", "5")
+	assertInvalidType(t, func() { p.ToMap() })
+
+	out, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.Equal(t, `["x"]`, string(out))
+}
+
+func TestNestedDataKeyIsKept(t *testing.T) {
+	nested := newParams(map[string]interface{}{dataKey: 5, "x": 1}).GetObject(dataKey)
+	assert.Equal(t, map[string]interface{}{dataKey: 5, "x": 1}, nested.Unwrap())
+	assert.Equal(t, map[string]interface{}{dataKey: 5, "x": 1}, nested.ToMap())
+	assert.Equal(t, map[string]interface{}{dataKey: 5, "x": 1}, nested.Clone().ToMap())
+	assert.Equal(t, map[string]interface{}{dataKey: 5, "x": 1}, newParams(map[string]interface{}{dataKey: 5, "x": 1}).Clone().Unwrap())
+}
+
+func TestMarshalParams(t *testing.T) {
+	out, err := json.Marshal(newParams(map[string]interface{}{"n": json.Number("9007199254740993")}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"n":9007199254740993}`, string(out))
+
+	out, err = json.Marshal(envelopedParams())
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"7","name":"a","tags":["x"]}`, string(out))
+
+	out, err = json.Marshal(map[string]interface{}{"params": envelopedParams().GetObject(dataKey).GetObject("tags")})
+	require.NoError(t, err)
+	assert.Equal(t, `{"params":["x"]}`, string(out))
+}
+
+func TestClone(t *testing.T) {
+	original := envelopedParams().GetObject(dataKey)
+	clone := original.Clone()
+	clone.Set("name", "b")
+	clone.Set("tags[0]", "y")
+	assert.Equal(t, "a", original.Get("name").StringValue())
+	assert.Equal(t, "x", original.Get("tags[0]").StringValue())
+	assert.Equal(t, "b", clone.Get("name").StringValue())
+}
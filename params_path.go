@@ -0,0 +1,214 @@
+package m3lshttp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mohamed-essam/m3lsh"
+)
+
+type PathNotFoundException struct {
+	m3lsh.BaseException
+	Path    string
+	Segment string
+}
+
+type pathSegment struct {
+	token     string
+	bracketed bool
+}
+
+// Get accepts a dotted path like "a.b[0].c" or an RFC 6901 JSON Pointer like "/a/b/0/c"
+func (p *ParamsWrapper) Get(path string) Params {
+	segments := parsePath(path)
+	value, failed := resolvePath(p.object, segments)
+	if failed != "" {
+		throwPathNotFound(path, failed)
+	}
+	return p.wrap(value, path, segments...)
+}
+
+func (p *ParamsWrapper) TryGet(path string) (Params, bool) {
+	segments := parsePath(path)
+	value, failed := resolvePath(p.object, segments)
+	if failed != "" {
+		return nil, false
+	}
+	return p.wrap(value, path, segments...), true
+}
+
+// Set creates missing objects along the path, an index equal to the array length appends.
+// On a child the change is written back through its parents; it throws PathNotFoundException
+// when the parent no longer has the child's location, e.g. after an array element was deleted
+func (p *ParamsWrapper) Set(path string, value interface{}) {
+	p.replace(setPath(p.object, parsePath(path), 0, value, path))
+}
+
+func (p *ParamsWrapper) Delete(path string) {
+	segments := parsePath(path)
+	if len(segments) == 0 {
+		p.replace(nil)
+		return
+	}
+	p.replace(deletePath(p.object, segments, 0, path))
+}
+
+// replace stores object in p and writes it back into every parent up to the root
+func (p *ParamsWrapper) replace(object interface{}) {
+	if p.parent != nil {
+		p.checkLocation()
+	}
+	p.object = object
+	if p.parent != nil {
+		p.parent.replace(setPath(p.parent.object, p.segments, 0, object, p.path))
+	}
+}
+
+// checkLocation throws when the parent no longer holds the array element the child was read from
+func (p *ParamsWrapper) checkLocation() {
+	if len(p.segments) == 0 {
+		return
+	}
+	last := p.segments[len(p.segments)-1]
+	holder, failed := resolvePath(p.parent.object, p.segments[:len(p.segments)-1])
+	if failed != "" {
+		throwPathNotFound(p.path, failed)
+	}
+	if ar, ok := holder.([]interface{}); ok {
+		if idx, err := strconv.Atoi(last.token); err != nil || idx < 0 || idx >= len(ar) {
+			throwPathNotFound(p.path, last.token)
+		}
+	}
+}
+
+func indexSegment(idx int) pathSegment {
+	return pathSegment{token: strconv.Itoa(idx), bracketed: true}
+}
+
+func throwPathNotFound(path, segment string) {
+	m3lsh.Throw(&PathNotFoundException{Path: path, Segment: segment}, fmt.Sprintf("Segment %q of %q not found", segment, path))
+}
+
+func parsePath(path string) []pathSegment {
+	if strings.HasPrefix(path, "/") {
+		return parsePointer(path)
+	}
+	segments := make([]pathSegment, 0)
+	if path == "" {
+		return segments
+	}
+	for _, part := range strings.Split(path, ".") {
+		key := part
+		idx := strings.Index(part, "[")
+		if idx >= 0 {
+			key = part[:idx]
+		}
+		if key != "" || idx < 0 {
+			segments = append(segments, pathSegment{token: key})
+		}
+		for idx >= 0 {
+			end := strings.Index(part[idx:], "]")
+			if end < 0 {
+				segments = append(segments, pathSegment{token: part[idx+1:], bracketed: true})
+				break
+			}
+			segments = append(segments, pathSegment{token: part[idx+1 : idx+end], bracketed: true})
+			part = part[idx+end+1:]
+			idx = strings.Index(part, "[")
+		}
+	}
+	return segments
+}
+
+func parsePointer(pointer string) []pathSegment {
+	segments := make([]pathSegment, 0)
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	for _, token := range strings.Split(pointer[1:], "/") {
+		segments = append(segments, pathSegment{token: unescape.Replace(token)})
+	}
+	return segments
+}
+
+// resolvePath returns the failing segment's token when the path cannot be followed
+func resolvePath(node interface{}, segments []pathSegment) (interface{}, string) {
+	for _, seg := range segments {
+		switch v := node.(type) {
+		case map[string]interface{}:
+			child, ok := v[seg.token]
+			if !ok || seg.bracketed {
+				return nil, seg.token
+			}
+			node = child
+		case []interface{}:
+			idx, err := strconv.Atoi(seg.token)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, seg.token
+			}
+			node = v[idx]
+		default:
+			return nil, seg.token
+		}
+	}
+	return node, ""
+}
+
+func setPath(node interface{}, segments []pathSegment, i int, value interface{}, path string) interface{} {
+	if i == len(segments) {
+		return value
+	}
+	seg := segments[i]
+	if ar, ok := node.([]interface{}); ok {
+		idx, err := strconv.Atoi(seg.token)
+		if seg.token == "-" {
+			idx, err = len(ar), nil
+		}
+		if err != nil || idx < 0 || idx > len(ar) {
+			throwPathNotFound(path, seg.token)
+		}
+		if idx == len(ar) {
+			ar = append(ar, nil)
+		}
+		ar[idx] = setPath(ar[idx], segments, i+1, value, path)
+		return ar
+	}
+	if node == nil {
+		node = make(map[string]interface{})
+	}
+	mp, ok := node.(map[string]interface{})
+	if !ok || seg.bracketed {
+		throwPathNotFound(path, seg.token)
+	}
+	mp[seg.token] = setPath(mp[seg.token], segments, i+1, value, path)
+	return mp
+}
+
+func deletePath(node interface{}, segments []pathSegment, i int, path string) interface{} {
+	seg := segments[i]
+	last := i == len(segments)-1
+	switch v := node.(type) {
+	case map[string]interface{}:
+		child, ok := v[seg.token]
+		if !ok || seg.bracketed {
+			throwPathNotFound(path, seg.token)
+		}
+		if last {
+			delete(v, seg.token)
+		} else {
+			v[seg.token] = deletePath(child, segments, i+1, path)
+		}
+		return v
+	case []interface{}:
+		idx, err := strconv.Atoi(seg.token)
+		if err != nil || idx < 0 || idx >= len(v) {
+			throwPathNotFound(path, seg.token)
+		}
+		if last {
+			return append(v[:idx], v[idx+1:]...)
+		}
+		v[idx] = deletePath(v[idx], segments, i+1, path)
+		return v
+	}
+	throwPathNotFound(path, seg.token)
+	return nil
+}
@@ -0,0 +1,130 @@
+package m3lshttp
+
+import (
+	"testing"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pathParams() Params {
+	return newParams(map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": []interface{}{
+				map[string]interface{}{"c": "deep"},
+				[]interface{}{"x", "y"},
+			},
+			"we/ird~key": "escaped",
+		},
+	}).GetObject("_data")
+}
+
+func TestParsePath(t *testing.T) {
+	assert.Equal(t, []pathSegment{{token: "a"}, {token: "b"}, {token: "0", bracketed: true}, {token: "c"}}, parsePath("a.b[0].c"))
+	assert.Equal(t, []pathSegment{{token: "a"}, {token: "1", bracketed: true}, {token: "2", bracketed: true}}, parsePath("a[1][2]"))
+	assert.Equal(t, []pathSegment{{token: "a"}, {token: "b/c"}, {token: "d~e"}}, parsePath("/a/b~1c/d~0e"))
+	assert.Empty(t, parsePath(""))
+}
+
+func TestGetDottedPath(t *testing.T) {
+	p := pathParams()
+	assert.Equal(t, "deep", p.Get("a.b[0].c").StringValue())
+	assert.Equal(t, "y", p.Get("a.b[1][1]").StringValue())
+}
+
+func TestGetJsonPointer(t *testing.T) {
+	p := pathParams()
+	assert.Equal(t, "deep", p.Get("/a/b/0/c").StringValue())
+	assert.Equal(t, "escaped", p.Get("/a/we~1ird~0key").StringValue())
+}
+
+func TestGetMissingSegment(t *testing.T) {
+	p := pathParams()
+	ex := m3lsh.Try(func() {
+		p.Get("a.b[5].c")
+		t.Error("Missing segment not reported")
+	})
+	require.IsType(t, &PathNotFoundException{}, ex)
+	notFound := ex.(*PathNotFoundException)
+	assert.Equal(t, "5", notFound.Segment)
+	assert.Equal(t, "a.b[5].c", notFound.Path)
+	assert.Equal(t, "Segment \"5\" of \"a.b[5].c\" not found", notFound.Message)
+}
+
+func TestTryGet(t *testing.T) {
+	p := pathParams()
+	value, ok := p.TryGet("a.b[0].c")
+	require.True(t, ok)
+	assert.Equal(t, "deep", value.StringValue())
+	_, ok = p.TryGet("a.missing")
+	assert.False(t, ok)
+}
+
+func TestSetPath(t *testing.T) {
+	p := pathParams()
+	p.Set("a.b[0].c", "changed")
+	p.Set("a.b[2]", "appended")
+	p.Set("/x/y", 5)
+	assert.Equal(t, "changed", p.Get("a.b[0].c").StringValue())
+	assert.Equal(t, "appended", p.Get("a.b[2]").StringValue())
+	assert.Equal(t, 5, p.Get("x.y").Integer())
+
+	ex := m3lsh.Try(func() {
+		p.Set("a.b[9]", "far")
+		t.Error("Out of range index not reported")
+	})
+	assert.IsType(t, &PathNotFoundException{}, ex)
+}
+
+func TestDeletePath(t *testing.T) {
+	p := pathParams()
+	p.Delete("a.b[0]")
+	p.Delete("/a/we~1ird~0key")
+	assert.Equal(t, "x", p.Get("a.b[0][0]").StringValue())
+	assert.False(t, p.Get("a").Has("we/ird~key"))
+
+	ex := m3lsh.Try(func() {
+		p.Delete("a.nothing")
+		t.Error("Missing key not reported")
+	})
+	assert.IsType(t, &PathNotFoundException{}, ex)
+}
+
+func TestChildWritesReachRoot(t *testing.T) {
+	root := newParams(map[string]interface{}{"a": []interface{}{"x"}, "b": []interface{}{"x", "y"}})
+	root.GetObject("_data").GetObject("a").Set("1", "y")
+	root.GetObject("_data").Get("b").Delete("[0]")
+	root.GetObject("_data").GetObject("c").Set("d", "new")
+	assert.Equal(t, map[string]interface{}{
+		"a": []interface{}{"x", "y"},
+		"b": []interface{}{"y"},
+		"c": map[string]interface{}{"d": "new"},
+	}, root.Unwrap())
+
+	list := newParams([]interface{}{[]interface{}{"x"}}).GetObject("_data")
+	list.GetArray(0).Set("-", "y")
+	list.Each(func(i int, item Params) {
+		item.Delete("[0]")
+	})
+	assert.Equal(t, []interface{}{[]interface{}{"y"}}, list.Unwrap())
+}
+
+func TestStaleChildWriteThrows(t *testing.T) {
+	data := newParams(map[string]interface{}{"a": []interface{}{"x", []interface{}{}}}).GetObject("_data")
+	child := data.Get("a[1]")
+	data.Delete("a[1]")
+	ex := m3lsh.Try(func() {
+		child.Set("-", "y")
+		t.Error("Stale child write not reported")
+	})
+	assert.IsType(t, &PathNotFoundException{}, ex)
+}
+
+func TestValidateNestedRules(t *testing.T) {
+	p := pathParams()
+	errors := fieldErrors(t, func() {
+		p.Validate(Rules{"a.b[0].c": {Len(2)}, "a.missing": {Required()}})
+	})
+	assert.Equal(t, map[string]string{"a.b[0].c": "must have length 2", "a.missing": "is required"}, errors)
+}
@@ -1,6 +1,9 @@
 package m3lshttp
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"testing"
 
 	"github.com/mohamed-essam/m3lsh"
@@ -314,3 +317,177 @@ func TestAsDoubleFromDouble(t *testing.T) {
 	assert.NotNil(t, obj)
 	assert.Equal(t, float64(5.3), obj)
 }
+
+func TestHas(t *testing.T) {
+	p := newParams(map[string]interface{}{"a": nil}).GetObject("_data")
+	assert.True(t, p.Has("a"))
+	assert.False(t, p.Has("b"))
+	assert.False(t, newParams("abc").GetObject("_data").Has("a"))
+}
+
+func TestIsNull(t *testing.T) {
+	p := newParams(map[string]interface{}{"a": nil, "b": "c"}).GetObject("_data")
+	assert.True(t, p.GetObject("a").IsNull())
+	assert.True(t, p.GetObject("missing").IsNull())
+	assert.False(t, p.GetObject("b").IsNull())
+}
+
+func TestKind(t *testing.T) {
+	p := newParams(map[string]interface{}{
+		"null":   nil,
+		"string": "a",
+		"number": float64(1),
+		"bool":   true,
+		"object": map[string]interface{}{},
+		"array":  []interface{}{},
+	}).GetObject("_data")
+	assert.Equal(t, ObjectKind, p.Kind())
+	assert.Equal(t, NullKind, p.GetObject("null").Kind())
+	assert.Equal(t, StringKind, p.GetObject("string").Kind())
+	assert.Equal(t, NumberKind, p.GetObject("number").Kind())
+	assert.Equal(t, BoolKind, p.GetObject("bool").Kind())
+	assert.Equal(t, ArrayKind, p.GetObject("array").Kind())
+}
+
+func TestTryObject(t *testing.T) {
+	p := newParams(map[string]interface{}{"a": "b"}).GetObject("_data")
+	obj, ok := p.TryObject("a")
+	require.True(t, ok)
+	assert.Equal(t, "b", obj.StringValue())
+	_, ok = p.TryObject("missing")
+	assert.False(t, ok)
+}
+
+func TestTryStringAndDefault(t *testing.T) {
+	p := newParams(map[string]interface{}{"a": "b", "n": float64(5)}).GetObject("_data")
+	str, ok := p.GetObject("a").TryString()
+	assert.True(t, ok)
+	assert.Equal(t, "b", str)
+	_, ok = p.GetObject("n").TryString()
+	assert.False(t, ok)
+	assert.Equal(t, "def", p.GetObject("missing").StringOr("def"))
+	assert.Equal(t, "b", p.GetObject("a").StringOr("def"))
+}
+
+func TestTryNumbersAndDefaults(t *testing.T) {
+	p := newParams(map[string]interface{}{"n": json.Number("5"), "s": "7", "bad": "x"}).GetObject("_data")
+	num, ok := p.GetObject("n").TryInteger()
+	assert.True(t, ok)
+	assert.Equal(t, 5, num)
+	_, ok = p.GetObject("s").TryLong()
+	assert.False(t, ok)
+	_, ok = p.GetObject("bad").TryDouble()
+	assert.False(t, ok)
+	_, ok = p.GetObject("missing").TryFloat()
+	assert.False(t, ok)
+
+	assert.Equal(t, 9, p.GetObject("bad").IntegerOr(9))
+	assert.Equal(t, int64(9), p.GetObject("missing").LongOr(9))
+	assert.Equal(t, float32(5), p.GetObject("n").FloatOr(9))
+	assert.Equal(t, 9.0, p.GetObject("s").DoubleOr(9))
+}
+
+func TestTryAsNumbersAndDefaults(t *testing.T) {
+	p := newParams(map[string]interface{}{"n": float64(5), "s": "7", "bad": "x"}).GetObject("_data")
+	num, ok := p.GetObject("n").TryAsInteger()
+	assert.True(t, ok)
+	assert.Equal(t, 5, num)
+	long, ok := p.GetObject("s").TryAsLong()
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), long)
+	_, ok = p.GetObject("bad").TryAsDouble()
+	assert.False(t, ok)
+	_, ok = p.GetObject("missing").TryAsFloat()
+	assert.False(t, ok)
+
+	assert.Equal(t, 9, p.GetObject("bad").AsIntegerOr(9))
+	assert.Equal(t, int64(9), p.GetObject("missing").AsLongOr(9))
+	assert.Equal(t, float32(5), p.GetObject("n").AsFloatOr(9))
+	assert.Equal(t, 7.0, p.GetObject("s").AsDoubleOr(9))
+}
+
+func TestGetArrayOutOfRange(t *testing.T) {
+	p := newParams([]interface{}{"a"})
+	ex := m3lsh.Try(func() {
+		p.GetObject("_data").GetArray(1)
+		t.Error("Not panicked")
+	})
+	require.IsType(t, &IndexOutOfRangeException{}, ex)
+	assert.Equal(t, 1, ex.(*IndexOutOfRangeException).Index)
+	assert.Equal(t, 1, ex.(*IndexOutOfRangeException).Length)
+}
+
+func TestLen(t *testing.T) {
+	assert.Equal(t, 2, newParams([]interface{}{"a", "b"}).GetObject("_data").Len())
+	assert.Equal(t, 1, newParams(map[string]interface{}{"a": "b"}).GetObject("_data").Len())
+	ex := m3lsh.Try(func() {
+		newParams("abc").GetObject("_data").Len()
+		t.Error("Not panicked")
+	})
+	assert.IsType(t, &InvalidTypeException{}, ex)
+}
+
+func TestEach(t *testing.T) {
+	p := newParams([]interface{}{"a", "b"}).GetObject("_data")
+	seen := make([]string, 0)
+	p.Each(func(i int, item Params) {
+		seen = append(seen, fmt.Sprintf("%d:%s", i, item.StringValue()))
+	})
+	assert.Equal(t, []string{"0:a", "1:b"}, seen)
+}
+
+func TestKeys(t *testing.T) {
+	p := newParams(map[string]interface{}{"b": 1, "a": 2}).GetObject("_data")
+	assert.Equal(t, []string{"a", "b"}, p.Keys())
+}
+
+func TestSliceConversions(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, newParams([]interface{}{"a", "b"}).GetObject("_data").StringSlice())
+	assert.Equal(t, []int{1, 2}, newParams([]interface{}{float64(1), "2"}).GetObject("_data").IntSlice())
+	assert.Equal(t, []float64{1.5, 2}, newParams([]interface{}{1.5, "2"}).GetObject("_data").DoubleSlice())
+	ex := m3lsh.Try(func() {
+		newParams([]interface{}{"a", float64(1)}).GetObject("_data").StringSlice()
+		t.Error("Not panicked")
+	})
+	assert.IsType(t, &InvalidTypeException{}, ex)
+}
+
+func TestJsonNumberAccessors(t *testing.T) {
+	p := newParams(map[string]interface{}{
+		"int":   json.Number("42"),
+		"big":   json.Number("9007199254740993"),
+		"frac":  json.Number("5.3"),
+		"huge":  json.Number("1e30"),
+		"oflow": json.Number("9223372036854775808"),
+	}).GetObject("_data")
+	assert.Equal(t, 42, p.GetObject("int").Integer())
+	assert.Equal(t, int64(9007199254740993), p.GetObject("big").Long())
+	assert.Equal(t, int64(9007199254740993), p.GetObject("big").AsLong())
+	assert.Equal(t, 5.3, p.GetObject("frac").Double())
+	assert.Equal(t, 5, p.GetObject("frac").AsInteger())
+	assert.Equal(t, float32(42), p.GetObject("int").AsFloat())
+	assert.Equal(t, NumberKind, p.GetObject("big").Kind())
+
+	for _, key := range []string{"huge", "oflow"} {
+		ex := m3lsh.Try(func() {
+			p.GetObject(key).AsLong()
+			t.Error("Not panicked")
+		})
+		assert.IsType(t, &InvalidTypeException{}, ex, key)
+	}
+	ex := m3lsh.Try(func() {
+		p.GetObject("frac").Integer()
+		t.Error("Not panicked")
+	})
+	assert.IsType(t, &InvalidTypeException{}, ex)
+}
+
+func TestAsIntegerOverflow(t *testing.T) {
+	ex := m3lsh.Try(func() {
+		newParams(1e300).GetObject("_data").AsInteger()
+		t.Error("Not panicked")
+	})
+	assert.IsType(t, &InvalidTypeException{}, ex)
+	_, ok := newParams(math.NaN()).GetObject("_data").TryAsInteger()
+	assert.False(t, ok)
+}
@@ -0,0 +1,152 @@
+package m3lshttp
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/mohamed-essam/m3lsh"
+)
+
+type UUID [16]byte
+
+func (u UUID) String() string {
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf)
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func ParseUUID(s string) (UUID, bool) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, false
+	}
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return u, false
+	}
+	return u, true
+}
+
+func (p ParamsWrapper) Bool() bool {
+	b, ok := p.object.(bool)
+	if !ok {
+		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not a bool")
+	}
+	return b
+}
+
+func (p ParamsWrapper) AsBool() bool {
+	switch v := p.object.(type) {
+	case bool:
+		return v
+	case string:
+		switch strings.ToLower(v) {
+		case "true", "t", "1", "on", "yes", "y":
+			return true
+		case "false", "f", "0", "off", "no", "n":
+			return false
+		}
+		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "String cannot be converted to bool")
+	case int, int64, float32, float64, json.Number:
+		switch p.AsDouble() {
+		case 1:
+			return true
+		case 0:
+			return false
+		}
+		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Number cannot be converted to bool")
+	}
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object cannot be converted to bool")
+	return false
+}
+
+// Time accepts RFC 3339 strings only; AsTime takes any layout and also unix seconds
+func (p ParamsWrapper) Time() time.Time {
+	switch v := p.object.(type) {
+	case time.Time:
+		return v
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t
+		}
+	}
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not an RFC 3339 time")
+	return time.Time{}
+}
+
+func (p ParamsWrapper) AsTime(layout string) time.Time {
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	switch v := p.object.(type) {
+	case time.Time:
+		return v
+	case string:
+		t, err := time.Parse(layout, v)
+		if err != nil {
+			m3lsh.Throw(&InvalidTypeException{Object: p.object}, "String cannot be converted to time")
+		}
+		return t
+	case int, int64, float32, float64, json.Number:
+		return time.Unix(p.AsLong(), 0).UTC()
+	}
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object cannot be converted to time")
+	return time.Time{}
+}
+
+func (p ParamsWrapper) Duration() time.Duration {
+	switch v := p.object.(type) {
+	case time.Duration:
+		return v
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not a duration")
+	return 0
+}
+
+func (p ParamsWrapper) UUID() UUID {
+	switch v := p.object.(type) {
+	case UUID:
+		return v
+	case string:
+		if u, ok := ParseUUID(v); ok {
+			return u
+		}
+	}
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not a UUID")
+	return UUID{}
+}
+
+// Bytes decodes standard or URL-safe base64, padded or not
+func (p ParamsWrapper) Bytes() []byte {
+	switch v := p.object.(type) {
+	case []byte:
+		return v
+	case string:
+		for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			if b, err := encoding.DecodeString(v); err == nil {
+				return b
+			}
+		}
+	}
+	m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not base64 encoded bytes")
+	return nil
+}
@@ -0,0 +1,95 @@
+package m3lshttp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func typedParams(object interface{}) Params {
+	return newParams(object).GetObject("_data")
+}
+
+func assertInvalidType(t *testing.T, fn func()) {
+	ex := m3lsh.Try(func() {
+		fn()
+		t.Error("Not panicked")
+	})
+	assert.IsType(t, &InvalidTypeException{}, ex)
+}
+
+func TestBool(t *testing.T) {
+	assert.True(t, typedParams(true).Bool())
+	assertInvalidType(t, func() { typedParams("true").Bool() })
+
+	for _, v := range []interface{}{true, "true", "1", "ON", "yes", json.Number("1"), float64(1)} {
+		assert.True(t, typedParams(v).AsBool(), "%v", v)
+	}
+	for _, v := range []interface{}{false, "false", "0", "off", "No", json.Number("0"), 0} {
+		assert.False(t, typedParams(v).AsBool(), "%v", v)
+	}
+	assertInvalidType(t, func() { typedParams("maybe").AsBool() })
+	assertInvalidType(t, func() { typedParams(float64(2)).AsBool() })
+}
+
+func TestTime(t *testing.T) {
+	expected := time.Date(2020, 5, 17, 10, 30, 0, 0, time.UTC)
+	assert.True(t, expected.Equal(typedParams("2020-05-17T10:30:00Z").Time()))
+	assertInvalidType(t, func() { typedParams("17/05/2020").Time() })
+
+	assert.True(t, time.Date(2020, 5, 17, 0, 0, 0, 0, time.UTC).Equal(typedParams("17/05/2020").AsTime("02/01/2006")))
+	assert.True(t, expected.Equal(typedParams("2020-05-17T10:30:00Z").AsTime("")))
+	assert.True(t, expected.Equal(typedParams(json.Number("1589711400")).AsTime("")))
+	assertInvalidType(t, func() { typedParams("2020").AsTime("02/01/2006") })
+}
+
+func TestDuration(t *testing.T) {
+	assert.Equal(t, 90*time.Second, typedParams("1m30s").Duration())
+	assertInvalidType(t, func() { typedParams("soon").Duration() })
+	assertInvalidType(t, func() { typedParams(float64(5)).Duration() })
+}
+
+func TestUUID(t *testing.T) {
+	u := typedParams("6BA7B810-9dad-11d1-80b4-00c04fd430c8").UUID()
+	assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", u.String())
+	assertInvalidType(t, func() { typedParams("6ba7b8109dad11d180b400c04fd430c8").UUID() })
+	assertInvalidType(t, func() { typedParams("6ba7b810-9dad-11d1-80b4-00c04fd430zz").UUID() })
+}
+
+func TestBytes(t *testing.T) {
+	assert.Equal(t, []byte("hi?>"), typedParams("aGk/Pg==").Bytes())
+	assert.Equal(t, []byte("hi?>"), typedParams("aGk_Pg").Bytes())
+	assertInvalidType(t, func() { typedParams("!!!").Bytes() })
+}
+
+func TestBindTypedFields(t *testing.T) {
+	var dst struct {
+		At      time.Time     `json:"at"`
+		Timeout time.Duration `json:"timeout"`
+		ID      UUID          `json:"id"`
+		Blob    []byte        `json:"blob"`
+		Active  bool          `json:"active"`
+	}
+	typedParams(map[string]interface{}{
+		"at":      "2020-05-17T10:30:00Z",
+		"timeout": "2s",
+		"id":      "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		"blob":    "aGk=",
+		"active":  "on",
+	}).Bind(&dst)
+	assert.Equal(t, 2020, dst.At.Year())
+	assert.Equal(t, 2*time.Second, dst.Timeout)
+	assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", dst.ID.String())
+	assert.Equal(t, []byte("hi"), dst.Blob)
+	assert.True(t, dst.Active)
+
+	ex := m3lsh.Try(func() {
+		typedParams(map[string]interface{}{"timeout": "soon", "id": "nope"}).Bind(&dst)
+	})
+	require.IsType(t, &UnprocessableEntity{}, ex)
+	assert.Len(t, ex.(*UnprocessableEntity).Fields["errors"], 2)
+}
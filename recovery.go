@@ -0,0 +1,96 @@
+package m3lshttp
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+)
+
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n%s", e.Value, e.Stack)
+}
+
+// recoverStream must be deferred directly inside a body stream writer, which fasthttp runs
+// on its own goroutine after the handler and recoverPanic have returned
+func recoverStream(r Request) {
+	if e := recover(); e != nil {
+		r.reportError(&PanicError{Value: e, Stack: debug.Stack()})
+	}
+}
+
+func (h *HttpHandler) MapException(exception interface{}, status int) {
+	h.exceptionStatus[reflect.TypeOf(exception)] = status
+}
+
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// recoverPanic must be deferred directly so recover() sees the panic
+func (h HttpHandler) recoverPanic(r Request) {
+	e := recover()
+	if e == nil {
+		return
+	}
+	if returned, isReturned := e.(handlerError); isReturned {
+		h.renderReturnedError(r, returned.err)
+		return
+	}
+	status, ok := h.statusFor(e)
+	if !ok {
+		r.reportError(&PanicError{Value: e, Stack: debug.Stack()})
+		h.renderError(r, 500, "", e)
+		return
+	}
+	if status >= 500 && !hasCause(e) {
+		r.reportError(&PanicError{Value: e, Stack: debug.Stack()})
+	}
+	h.renderException(r, status, e)
+}
+
+func (h HttpHandler) renderException(r Request, status int, e interface{}) {
+	if hasCause(e) {
+		r.reportError(e.(*InternalServerError).Cause)
+	}
+	if setter, ok := e.(ResponseHeaderSetter); ok {
+		setter.SetResponseHeaders(&r.context().Response.Header)
+	}
+	h.renderError(r, status, exceptionMessage(e), e)
+}
+
+// renderReturnedError maps the first error in the wrap chain that has a status
+func (h HttpHandler) renderReturnedError(r Request, err error) {
+	for current := err; current != nil; current = errors.Unwrap(current) {
+		if status, ok := h.statusFor(current); ok {
+			if status >= 500 && !hasCause(current) {
+				r.reportError(err)
+			}
+			h.renderException(r, status, current)
+			return
+		}
+	}
+	r.reportError(err)
+	h.renderError(r, 500, "", err)
+}
+
+// hasCause reports whether renderException will report e's Cause itself
+func hasCause(e interface{}) bool {
+	ex, ok := e.(*InternalServerError)
+	return ok && ex.Cause != nil
+}
+
+func (h HttpHandler) statusFor(ex interface{}) (int, bool) {
+	if status, ok := h.exceptionStatus[reflect.TypeOf(ex)]; ok {
+		return status, true
+	}
+	if coder, ok := ex.(StatusCoder); ok {
+		return coder.StatusCode(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,140 @@
+package m3lshttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type conflictException struct {
+	m3lsh.BaseException
+}
+
+func TestRecoverPlainPanic(t *testing.T) {
+	handler := NewHttpHandler()
+	var reported error
+	handler.OnError(func(r Request, err error) {
+		reported = err
+	})
+	handler.GET("/api/crash", func(r Request) {
+		var m map[string]int
+		m["a"] = 1
+	})
+	ctx := handleRequest(handler, "GET", "/api/crash")
+	assert.Equal(t, 500, ctx.Response.StatusCode())
+	require.IsType(t, &PanicError{}, reported)
+	assert.NotEmpty(t, reported.(*PanicError).Stack)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(ctx.Response.Body(), &body))
+	assert.NotContains(t, body, "detail")
+}
+
+func TestRecoverOutOfRange(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.POST("/api/items", func(r Request) {
+		r.Params().GetObject("_data").GetArray(5)
+	})
+	status, _ := tempServer(handler.handle, "/api/items", "[1]", "POST", t)
+	assert.Equal(t, 422, status)
+}
+
+func TestRecoverInvalidType(t *testing.T) {
+	handler := NewHttpHandler()
+	reported := false
+	handler.OnError(func(r Request, err error) {
+		reported = true
+	})
+	handler.GET("/api/users/:id", func(r Request) {
+		r.Params().GetObject("id").Integer()
+	})
+	ctx := handleRequest(handler, "GET", "/api/users/abc")
+	assert.Equal(t, 422, ctx.Response.StatusCode())
+	assert.False(t, reported)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(ctx.Response.Body(), &body))
+	assert.Equal(t, "Object is not an int", body["detail"])
+}
+
+func TestRecoverMappedException(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.MapException(&InvalidTypeException{}, 400)
+	handler.MapException(&conflictException{}, 409)
+	handler.GET("/api/users/:id", func(r Request) {
+		r.Params().GetObject("id").Integer()
+	})
+	handler.POST("/api/users", func(r Request) {
+		m3lsh.Throw(&conflictException{}, "User exists")
+	})
+	assert.Equal(t, 400, handleRequest(handler, "GET", "/api/users/abc").Response.StatusCode())
+	assert.Equal(t, 409, handleRequest(handler, "POST", "/api/users").Response.StatusCode())
+}
+
+type teapotException struct {
+	m3lsh.BaseException
+}
+
+func (teapotException) StatusCode() int {
+	return 418
+}
+
+func TestRecoverStatusCoder(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.GET("/api/coffee", func(r Request) {
+		m3lsh.Throw(&teapotException{}, "I'm a teapot")
+	})
+	ctx := handleRequest(handler, "GET", "/api/coffee")
+	assert.Equal(t, 418, ctx.Response.StatusCode())
+}
+
+func TestMapExceptionOverridesBuiltIn(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.MapException(&NotFound{}, 410)
+	ctx := handleRequest(handler, "GET", "/missing")
+	assert.Equal(t, 410, ctx.Response.StatusCode())
+}
+
+func TestRecoverReportsMappedServerErrors(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.MapException(&conflictException{}, 502)
+	var reported []error
+	handler.OnError(func(r Request, err error) {
+		reported = append(reported, err)
+	})
+	handler.GET("/api/upstream", func(r Request) {
+		m3lsh.Throw(&conflictException{}, "Upstream failed")
+	})
+	handler.GET("/api/unavailable", func(r Request) error {
+		return &ServiceUnavailable{}
+	})
+	handler.GET("/api/conflict", func(r Request) {
+		m3lsh.Throw(&Conflict{}, "User exists")
+	})
+	assert.Equal(t, 502, handleRequest(handler, "GET", "/api/upstream").Response.StatusCode())
+	assert.Equal(t, 503, handleRequest(handler, "GET", "/api/unavailable").Response.StatusCode())
+	assert.Equal(t, 409, handleRequest(handler, "GET", "/api/conflict").Response.StatusCode())
+	require.Len(t, reported, 2)
+	require.IsType(t, &PanicError{}, reported[0])
+	assert.IsType(t, &conflictException{}, reported[0].(*PanicError).Value)
+	assert.IsType(t, &ServiceUnavailable{}, reported[1])
+}
+
+func TestRecoverLogsPanicsByDefault(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+	handler := NewHttpHandler()
+	handler.GET("/api/crash", func(r Request) {
+		panic("boom")
+	})
+	assert.Equal(t, 500, handleRequest(handler, "GET", "/api/crash").Response.StatusCode())
+	assert.Contains(t, out.String(), "m3lshttp: GET /api/crash: panic: boom")
+	assert.Contains(t, out.String(), "runtime/debug.Stack")
+}
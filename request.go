@@ -1,6 +1,11 @@
 package m3lshttp
 
-import "github.com/valyala/fasthttp"
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	"github.com/valyala/fasthttp"
+)
 
 type Request interface {
 	pushPathParam(name, value string)
@@ -11,7 +16,11 @@ type Request interface {
 	MultipartForm() map[string][]string
 	Path() string
 	Method() string
+	TLS() *tls.ConnectionState
+	PeerCertificate() *x509.Certificate
 	context() *fasthttp.RequestCtx
+	reportError(err error)
+	setDecodeOptions(options *DecodeOptions)
 }
 
 type RequestWrapper struct {
@@ -20,12 +29,12 @@ type RequestWrapper struct {
 	pathParams          []string
 	pathValues          []string
 	pathParamsEvaluated bool
+	errorHook           ErrorHook
+	decodeOptions       *DecodeOptions
 }
 
 func newRequest(ctx *fasthttp.RequestCtx) *RequestWrapper {
-	req := &RequestWrapper{ctx: ctx, pathParams: make([]string, 0), pathValues: make([]string, 0)}
-	req.params = parseBody(req)
-	return req
+	return &RequestWrapper{ctx: ctx, pathParams: make([]string, 0), pathValues: make([]string, 0)}
 }
 
 func (r *RequestWrapper) pushPathParam(name, value string) {
@@ -38,7 +47,11 @@ func (r *RequestWrapper) popPathParam() {
 	r.pathValues = r.pathValues[:len(r.pathValues)-1]
 }
 
+// the body is parsed on first use so route options can still change how it is decoded
 func (r *RequestWrapper) Params() Params {
+	if r.params == nil {
+		r.params = parseBodyWith(r, r.decodeOptions)
+	}
 	if r.pathParamsEvaluated {
 		return r.params
 	}
@@ -69,6 +82,31 @@ func (r *RequestWrapper) Method() string {
 	return string(r.ctx.Method())
 }
 
+// TLS is nil for plain HTTP connections
+func (r *RequestWrapper) TLS() *tls.ConnectionState {
+	return r.ctx.TLSConnectionState()
+}
+
+// PeerCertificate is the client certificate, or nil when the client sent none or it was not verified
+func (r *RequestWrapper) PeerCertificate() *x509.Certificate {
+	state := r.TLS()
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
+
 func (r *RequestWrapper) context() *fasthttp.RequestCtx {
 	return r.ctx
 }
+
+func (r *RequestWrapper) reportError(err error) {
+	if r.errorHook != nil {
+		r.errorHook(r, err)
+	}
+}
+
+func (r *RequestWrapper) setDecodeOptions(options *DecodeOptions) {
+	r.decodeOptions = options
+	r.params = nil
+}
@@ -0,0 +1,90 @@
+package m3lshttp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mohamed-essam/m3lsh"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ServerConfig mirrors the fasthttp.Server limits; zero values keep the fasthttp defaults.
+// MaxRequestBodySize applies to routes without WithMaxBodySize; the server itself accepts
+// bodies up to the largest WithMaxBodySize when that is bigger
+type ServerConfig struct {
+	Name               string
+	Concurrency        int
+	ReadTimeout        time.Duration
+	WriteTimeout       time.Duration
+	IdleTimeout        time.Duration
+	MaxRequestBodySize int
+	MaxConnsPerIP      int
+	MaxRequestsPerConn int
+	// ReadBufferSize also caps the size of the request headers
+	ReadBufferSize    int
+	WriteBufferSize   int
+	DisableKeepalive  bool
+	ReduceMemoryUsage bool
+}
+
+func (h *HttpHandler) Configure(config ServerConfig) {
+	h.config = config
+}
+
+func (h *HttpHandler) Server() *fasthttp.Server {
+	c := h.config
+	return &fasthttp.Server{
+		Handler:            h.handle,
+		Name:               c.Name,
+		Concurrency:        c.Concurrency,
+		ReadTimeout:        c.ReadTimeout,
+		WriteTimeout:       c.WriteTimeout,
+		IdleTimeout:        c.IdleTimeout,
+		MaxRequestBodySize: h.serverBodySize(),
+		MaxConnsPerIP:      c.MaxConnsPerIP,
+		MaxRequestsPerConn: c.MaxRequestsPerConn,
+		ReadBufferSize:     c.ReadBufferSize,
+		WriteBufferSize:    c.WriteBufferSize,
+		DisableKeepalive:   c.DisableKeepalive,
+		ReduceMemoryUsage:  c.ReduceMemoryUsage,
+		ConnState:          h.state.trackConn,
+	}
+}
+
+// WithMaxBodySize overrides ServerConfig.MaxRequestBodySize for one route. A size above it raises
+// the limit of the whole server: fasthttp reads every request body, on any route or a 404, into
+// memory up to that size before the smaller route limits reject it with 413
+func WithMaxBodySize(size int) RouteOption {
+	return func(rt *route) {
+		rt.maxBodySize = size
+	}
+}
+
+func (h *HttpHandler) bodySize() int {
+	if h.config.MaxRequestBodySize > 0 {
+		return h.config.MaxRequestBodySize
+	}
+	return fasthttp.DefaultMaxRequestBodySize
+}
+
+// the server has to accept the largest per-route limit, the smaller limits are enforced by limitBodySize
+func (h *HttpHandler) serverBodySize() int {
+	if h.maxRouteBodySize > h.bodySize() {
+		return h.maxRouteBodySize
+	}
+	return h.bodySize()
+}
+
+func (h *HttpHandler) limitBodySize(next handler, size int) handler {
+	return func(r Request) {
+		limit := size
+		if limit == 0 {
+			limit = h.bodySize()
+		}
+		if len(r.Body()) > limit {
+			m3lsh.Throw(&PayloadTooLarge{}, fmt.Sprintf("Request body exceeds %d bytes", limit))
+		}
+		next(r)
+	}
+}
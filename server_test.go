@@ -0,0 +1,60 @@
+package m3lshttp
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func sendRequest(t *testing.T, c net.Conn, method, path, body string) int {
+	req := fmt.Sprintf("%s %s HTTP/1.1\r\nHost: 7amada\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", method, path, len(body), body)
+	_, err := c.Write([]byte(req))
+	require.NoError(t, err)
+	var resp fasthttp.Response
+	require.NoError(t, resp.Read(bufio.NewReader(c)))
+	return resp.StatusCode()
+}
+
+func serveRequest(t *testing.T, s *fasthttp.Server, method, path, body string) int {
+	ln := fasthttputil.NewInmemoryListener()
+	defer ln.Close()
+	go s.Serve(ln)
+	c, err := ln.Dial()
+	require.NoError(t, err)
+	defer c.Close()
+	return sendRequest(t, c, method, path, body)
+}
+
+func TestServerConfig(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.Configure(ServerConfig{Name: "m3lsh", ReadTimeout: time.Second, IdleTimeout: 2 * time.Second, Concurrency: 10, MaxRequestBodySize: 100})
+	s := handler.Server()
+	assert.Equal(t, "m3lsh", s.Name)
+	assert.Equal(t, time.Second, s.ReadTimeout)
+	assert.Equal(t, 2*time.Second, s.IdleTimeout)
+	assert.Equal(t, 10, s.Concurrency)
+	assert.Equal(t, 100, s.MaxRequestBodySize)
+
+	assert.Equal(t, fasthttp.DefaultMaxRequestBodySize, NewHttpHandler().Server().MaxRequestBodySize)
+}
+
+func TestRouteMaxBodySize(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.Configure(ServerConfig{MaxRequestBodySize: 10})
+	handler.POST("/upload", func(r Request) {}, WithMaxBodySize(20))
+	handler.POST("/small", func(r Request) {})
+	assert.Equal(t, 20, handler.Server().MaxRequestBodySize)
+
+	body := strings.Repeat("a", 15)
+	assert.Equal(t, 200, serveRequest(t, handler.Server(), "POST", "/upload", body))
+	assert.Equal(t, 413, serveRequest(t, handler.Server(), "POST", "/small", body))
+	assert.Equal(t, 200, serveRequest(t, handler.Server(), "POST", "/small", "short"))
+}
@@ -0,0 +1,175 @@
+package m3lshttp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+var ErrServerShutdown = errors.New("m3lshttp: server is shutting down")
+
+// ShutdownIdleGrace is how long Shutdown leaves a connection that is not serving a request open.
+// fasthttp only marks a connection active once a whole request has been read, so a connection
+// that is new or idle may still be receiving one.
+var ShutdownIdleGrace = 5 * time.Second
+
+const shutdownPollInterval = 100 * time.Millisecond
+
+type ShutdownHook func(ctx context.Context) error
+
+type serverState struct {
+	lock         sync.Mutex
+	servers      []*fasthttp.Server
+	listeners    []net.Listener
+	conns        map[net.Conn]trackedConn
+	shuttingDown bool
+	hooks        []ShutdownHook
+}
+
+type trackedConn struct {
+	state fasthttp.ConnState
+	since time.Time
+}
+
+// shutdownListener can be closed by beginShutdown and again by fasthttp.Server.Shutdown
+type shutdownListener struct {
+	net.Listener
+	once sync.Once
+	err  error
+}
+
+func (l *shutdownListener) Close() error {
+	l.once.Do(func() { l.err = l.Listener.Close() })
+	return l.err
+}
+
+func newServerState() *serverState {
+	return &serverState{conns: make(map[net.Conn]trackedConn)}
+}
+
+func (h *HttpHandler) Serve(ln net.Listener) error {
+	s := h.Server()
+	ln = &shutdownListener{Listener: ln}
+	if !h.state.addServer(s, ln) {
+		ln.Close()
+		return ErrServerShutdown
+	}
+	return s.Serve(ln)
+}
+
+// OnShutdown hooks run once, in registration order, after the servers have drained or ctx has expired
+func (h *HttpHandler) OnShutdown(hook ShutdownHook) {
+	h.state.lock.Lock()
+	defer h.state.lock.Unlock()
+	h.state.hooks = append(h.state.hooks, hook)
+}
+
+// Shutdown stops accepting connections, closes connections that stay idle for ShutdownIdleGrace and
+// waits for in-flight requests to finish; it returns ctx.Err() if they are still running when ctx expires
+func (h *HttpHandler) Shutdown(ctx context.Context) error {
+	servers, hooks := h.state.beginShutdown()
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		for _, s := range servers {
+			if shutdownErr := s.Shutdown(); shutdownErr != nil && err == nil {
+				err = shutdownErr
+			}
+		}
+		done <- err
+	}()
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	var err error
+wait:
+	for {
+		h.state.closeIdle(ShutdownIdleGrace)
+		select {
+		case err = <-done:
+			break wait
+		case <-ctx.Done():
+			err = ctx.Err()
+			break wait
+		case <-ticker.C:
+		}
+	}
+	for _, hook := range hooks {
+		if hookErr := hook(ctx); hookErr != nil && err == nil {
+			err = hookErr
+		}
+	}
+	return err
+}
+
+// ShutdownOnSignal calls Shutdown with the given timeout when one of signals arrives,
+// SIGINT and SIGTERM by default; the result of Shutdown is sent on the returned channel
+func (h *HttpHandler) ShutdownOnSignal(timeout time.Duration, signals ...os.Signal) <-chan error {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	result := make(chan error, 1)
+	go func() {
+		<-received
+		signal.Stop(received)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		result <- h.Shutdown(ctx)
+	}()
+	return result
+}
+
+func (s *serverState) addServer(server *fasthttp.Server, ln net.Listener) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.shuttingDown {
+		return false
+	}
+	s.servers = append(s.servers, server)
+	s.listeners = append(s.listeners, ln)
+	return true
+}
+
+func (s *serverState) beginShutdown() ([]*fasthttp.Server, []ShutdownHook) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.shuttingDown = true
+	// a server that is still starting up has not handed its listener to fasthttp yet,
+	// so fasthttp.Server.Shutdown would not close it
+	for _, ln := range s.listeners {
+		ln.Close()
+	}
+	servers, hooks := s.servers, s.hooks
+	s.servers, s.listeners, s.hooks = nil, nil, nil
+	return servers, hooks
+}
+
+func (s *serverState) closeIdle(grace time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for conn, tracked := range s.conns {
+		if tracked.state != fasthttp.StateActive && time.Since(tracked.since) >= grace {
+			conn.Close()
+		}
+	}
+}
+
+// trackConn is the fasthttp.Server ConnState callback
+func (s *serverState) trackConn(conn net.Conn, state fasthttp.ConnState) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch state {
+	case fasthttp.StateClosed, fasthttp.StateHijacked:
+		delete(s.conns, conn)
+		return
+	}
+	s.conns[conn] = trackedConn{state: state, since: time.Now()}
+}
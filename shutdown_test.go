@@ -0,0 +1,161 @@
+package m3lshttp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	handler := NewHttpHandler()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler.GET("/slow", func(r Request) {
+		close(entered)
+		<-release
+	})
+	hookCalled := false
+	handler.OnShutdown(func(ctx context.Context) error {
+		hookCalled = true
+		return nil
+	})
+
+	ln := fasthttputil.NewInmemoryListener()
+	served := make(chan error, 1)
+	go func() { served <- handler.Serve(ln) }()
+	c, err := ln.Dial()
+	require.NoError(t, err)
+	defer c.Close()
+	status := make(chan int, 1)
+	go func() { status <- sendRequest(t, c, "GET", "/slow", "") }()
+	<-entered
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- handler.Shutdown(ctx)
+	}()
+	select {
+	case <-shutdown:
+		t.Fatal("Shutdown returned before the request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+
+	assert.Equal(t, 200, <-status)
+	assert.NoError(t, <-shutdown)
+	assert.NoError(t, <-served)
+	assert.True(t, hookCalled)
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	handler := NewHttpHandler()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler.GET("/slow", func(r Request) {
+		close(entered)
+		<-release
+	})
+	hookErr := errors.New("pool already closed")
+	handler.OnShutdown(func(ctx context.Context) error { return hookErr })
+
+	ln := fasthttputil.NewInmemoryListener()
+	go handler.Serve(ln)
+	c, err := ln.Dial()
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = c.Write([]byte("GET /slow HTTP/1.1\r\nHost: 7amada\r\n\r\n"))
+	require.NoError(t, err)
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, handler.Shutdown(ctx))
+}
+
+func TestShutdownClosesIdleConnections(t *testing.T) {
+	defer func(grace time.Duration) { ShutdownIdleGrace = grace }(ShutdownIdleGrace)
+	ShutdownIdleGrace = 50 * time.Millisecond
+	handler := NewHttpHandler()
+	handler.GET("/", func(r Request) {})
+	ln := fasthttputil.NewInmemoryListener()
+	go handler.Serve(ln)
+	c, err := ln.Dial()
+	require.NoError(t, err)
+	defer c.Close()
+	assert.Equal(t, 200, sendRequest(t, c, "GET", "/", ""))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, handler.Shutdown(ctx))
+	assert.Equal(t, ErrServerShutdown, handler.Serve(fasthttputil.NewInmemoryListener()))
+}
+
+func TestShutdownKeepsUploadingConnections(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.POST("/upload", func(r Request) {})
+	ln := fasthttputil.NewInmemoryListener()
+	go handler.Serve(ln)
+	c, err := ln.Dial()
+	require.NoError(t, err)
+	defer c.Close()
+	_, err = c.Write([]byte("POST /upload HTTP/1.1\r\nHost: 7amada\r\nContent-Length: 10\r\n\r\n01234"))
+	require.NoError(t, err)
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- handler.Shutdown(ctx)
+	}()
+	time.Sleep(3 * shutdownPollInterval / 2)
+	_, err = c.Write([]byte("56789"))
+	require.NoError(t, err)
+
+	var resp fasthttp.Response
+	require.NoError(t, resp.Read(bufio.NewReader(c)))
+	assert.Equal(t, 200, resp.StatusCode())
+	assert.NoError(t, <-shutdown)
+}
+
+func TestShutdownHooksRunOnce(t *testing.T) {
+	handler := NewHttpHandler()
+	calls := 0
+	handler.OnShutdown(func(ctx context.Context) error {
+		calls++
+		return nil
+	})
+	assert.NoError(t, handler.Shutdown(context.Background()))
+	assert.NoError(t, handler.Shutdown(context.Background()))
+	assert.Equal(t, 1, calls)
+}
+
+func TestShutdownBeforeServeStarts(t *testing.T) {
+	handler := NewHttpHandler()
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := handler.Server()
+	wrapped := &shutdownListener{Listener: ln}
+	require.True(t, handler.state.addServer(s, wrapped))
+	assert.NoError(t, handler.Shutdown(context.Background()))
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(wrapped)
+	}()
+	select {
+	case <-served:
+	case <-time.After(time.Second):
+		t.Fatal("Serve kept accepting after Shutdown")
+	}
+}
@@ -0,0 +1,151 @@
+package m3lshttp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var SSEKeepAlive = 15 * time.Second
+
+var errStreamClosed = errors.New("event stream closed by client")
+
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+type EventStream struct {
+	lastEventID string
+	params      Params
+	w           *bufio.Writer
+	lock        sync.Mutex
+	done        chan struct{}
+	closed      bool
+}
+
+func (h *HttpHandler) SSE(path string, fn func(s *EventStream)) {
+	h.GET(path, func(r Request) {
+		ctx := r.context()
+		lastEventID := string(ctx.Request.Header.Peek("Last-Event-ID"))
+		params := r.Params()
+		ctx.Response.Header.SetContentType("text/event-stream")
+		ctx.Response.Header.Set("Cache-Control", "no-cache")
+		ctx.Response.Header.Set("X-Accel-Buffering", "no")
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer recoverStream(r)
+			s := newEventStream(w, lastEventID, params)
+			defer s.close()
+			stop := s.keepAlive(SSEKeepAlive)
+			defer stop()
+			fn(s)
+		})
+	})
+}
+
+func newEventStream(w *bufio.Writer, lastEventID string, params Params) *EventStream {
+	return &EventStream{w: w, lastEventID: lastEventID, params: params, done: make(chan struct{})}
+}
+
+func (s *EventStream) LastEventID() string {
+	return s.lastEventID
+}
+
+func (s *EventStream) Params() Params {
+	return s.params
+}
+
+// Done is closed once a write fails, which is how a client disconnect shows up
+func (s *EventStream) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *EventStream) Send(e Event) error {
+	buf := &bytes.Buffer{}
+	if e.ID != "" {
+		fmt.Fprintf(buf, "id: %s\n", singleLine(e.ID))
+	}
+	if e.Event != "" {
+		fmt.Fprintf(buf, "event: %s\n", singleLine(e.Event))
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(buf, "retry: %d\n", e.Retry/time.Millisecond)
+	}
+	for _, line := range strings.Split(strings.Replace(e.Data, "\r\n", "\n", -1), "\n") {
+		fmt.Fprintf(buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+	return s.write(buf.Bytes())
+}
+
+func (s *EventStream) Comment(text string) error {
+	return s.write([]byte(fmt.Sprintf(": %s\n\n", singleLine(text))))
+}
+
+func (s *EventStream) write(b []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return errStreamClosed
+	}
+	_, err := s.w.Write(b)
+	if err == nil {
+		err = s.w.Flush()
+	}
+	if err != nil {
+		s.markClosed()
+	}
+	return err
+}
+
+// close stops writes once the stream callback returns, since fasthttp reuses w afterwards
+func (s *EventStream) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.closed {
+		s.markClosed()
+	}
+}
+
+func (s *EventStream) markClosed() {
+	s.closed = true
+	close(s.done)
+}
+
+// keepAlive returns a stop function that waits for the keep-alive goroutine to exit
+func (s *EventStream) keepAlive(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+	quit := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if s.Comment("keep-alive") != nil {
+					return
+				}
+			case <-quit:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-exited
+	}
+}
+
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,121 @@
+package m3lshttp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestEventStreamSend(t *testing.T) {
+	buf := &bytes.Buffer{}
+	s := newEventStream(bufio.NewWriter(buf), "", nil)
+	err := s.Send(Event{ID: "7", Event: "order", Data: "line1\nline2", Retry: 3 * time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 7\nevent: order\nretry: 3000\ndata: line1\ndata: line2\n\n", buf.String())
+}
+
+func TestEventStreamComment(t *testing.T) {
+	buf := &bytes.Buffer{}
+	s := newEventStream(bufio.NewWriter(buf), "", nil)
+	assert.NoError(t, s.Comment("keep-alive"))
+	assert.Equal(t, ": keep-alive\n\n", buf.String())
+}
+
+func TestEventStreamDisconnect(t *testing.T) {
+	s := newEventStream(bufio.NewWriter(failingWriter{}), "", nil)
+	assert.Error(t, s.Send(Event{Data: "a"}))
+	select {
+	case <-s.Done():
+	default:
+		t.Error("Disconnect not detected")
+	}
+	assert.Equal(t, errStreamClosed, s.Send(Event{Data: "b"}))
+}
+
+func TestEventStreamKeepAlive(t *testing.T) {
+	buf := &bytes.Buffer{}
+	s := newEventStream(bufio.NewWriter(buf), "", nil)
+	stop := s.keepAlive(5 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+	written := buf.String()
+	assert.Contains(t, written, ": keep-alive\n\n")
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, written, buf.String())
+}
+
+func TestEventStreamClose(t *testing.T) {
+	s := newEventStream(bufio.NewWriter(&bytes.Buffer{}), "", nil)
+	s.close()
+	s.close()
+	assert.Equal(t, errStreamClosed, s.Send(Event{Data: "late"}))
+	select {
+	case <-s.Done():
+	default:
+		t.Error("Done not closed")
+	}
+}
+
+func TestIntegrationSSE(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.SSE("/api/orders/:id/events", func(s *EventStream) {
+		id := s.Params().GetObject("id").StringValue()
+		s.Send(Event{ID: "1", Event: "status", Data: id + " shipped"})
+	})
+	status, body := tempServer(func(ctx *fasthttp.RequestCtx) {
+		handler.handle(ctx)
+	}, "/api/orders/42/events", "", "GET", t)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "id: 1\nevent: status\ndata: 42 shipped\n\n", string(body))
+}
+
+func TestSSELastEventID(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.SSE("/api/orders", func(s *EventStream) {
+		s.Send(Event{Data: "resume after " + s.LastEventID()})
+	})
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/api/orders")
+	ctx.Request.Header.SetMethod("GET")
+	ctx.Request.Header.Set("Last-Event-ID", "41")
+	handler.handle(ctx)
+	assert.Equal(t, "text/event-stream", string(ctx.Response.Header.ContentType()))
+	assert.Equal(t, "no-cache", string(ctx.Response.Header.Peek("Cache-Control")))
+	buf := &bytes.Buffer{}
+	assert.NoError(t, ctx.Response.BodyWriteTo(buf))
+	assert.Equal(t, "data: resume after 41\n\n", buf.String())
+}
+
+func TestSSEPanic(t *testing.T) {
+	handler := NewHttpHandler()
+	reported := make(chan error, 1)
+	handler.OnError(func(r Request, err error) {
+		reported <- err
+	})
+	handler.SSE("/api/orders/:id/events", func(s *EventStream) {
+		s.Send(Event{Data: "first"})
+		s.Params().GetObject("id").Integer()
+	})
+	status, body := tempServer(func(ctx *fasthttp.RequestCtx) {
+		handler.handle(ctx)
+	}, "/api/orders/42/events", "", "GET", t)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "data: first\n\n", string(body))
+	select {
+	case err := <-reported:
+		assert.IsType(t, &InvalidTypeException{}, err.(*PanicError).Value)
+	case <-time.After(time.Second):
+		t.Error("Stream panic not reported")
+	}
+}
@@ -0,0 +1,175 @@
+package m3lshttp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+func (h *HttpHandler) ListenAndServeTLS(addr, certFile, keyFile string) error {
+	store := NewCertStore()
+	if err := store.AddFile(certFile, keyFile); err != nil {
+		return err
+	}
+	return h.ListenAndServeTLSConfig(addr, store.TLSConfig())
+}
+
+func (h *HttpHandler) ListenAndServeTLSConfig(addr string, config *tls.Config) error {
+	ln, err := net.Listen("tcp4", addr)
+	if err != nil {
+		return err
+	}
+	return h.ServeTLS(ln, config)
+}
+
+func (h *HttpHandler) ServeTLS(ln net.Listener, config *tls.Config) error {
+	return h.Serve(tls.NewListener(ln, config))
+}
+
+// CertStore picks a certificate by SNI and can reload its files from disk without a restart
+type CertStore struct {
+	lock    sync.RWMutex
+	entries []*certEntry
+}
+
+type certEntry struct {
+	certFile string
+	keyFile  string
+	modTime  time.Time
+	cert     *tls.Certificate
+	leaf     *x509.Certificate
+}
+
+func NewCertStore() *CertStore {
+	return &CertStore{}
+}
+
+func (s *CertStore) AddFile(certFile, keyFile string) error {
+	entry := &certEntry{certFile: certFile, keyFile: keyFile}
+	if err := entry.load(); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+// Reload re-reads every file; a certificate that fails to load keeps serving its previous version
+func (s *CertStore) Reload() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var err error
+	for _, entry := range s.entries {
+		if loadErr := entry.load(); loadErr != nil && err == nil {
+			err = loadErr
+		}
+	}
+	return err
+}
+
+// WatchReload reloads the certificates whose files changed every interval until stop is called
+func (s *CertStore) WatchReload(interval time.Duration, onError func(err error)) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := s.reloadChanged(); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func (s *CertStore) reloadChanged() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var err error
+	for _, entry := range s.entries {
+		if !entry.changed() {
+			continue
+		}
+		if loadErr := entry.load(); loadErr != nil && err == nil {
+			err = loadErr
+		}
+	}
+	return err
+}
+
+func (s *CertStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if len(s.entries) == 0 {
+		return nil, errors.New("m3lshttp: no certificates loaded")
+	}
+	if hello.ServerName != "" {
+		for _, entry := range s.entries {
+			if entry.leaf.VerifyHostname(hello.ServerName) == nil {
+				return entry.cert, nil
+			}
+		}
+	}
+	return s.entries[0].cert, nil
+}
+
+func (s *CertStore) TLSConfig() *tls.Config {
+	return &tls.Config{GetCertificate: s.GetCertificate, MinVersion: tls.VersionTLS12}
+}
+
+func (e *certEntry) load() error {
+	cert, err := tls.LoadX509KeyPair(e.certFile, e.keyFile)
+	if err != nil {
+		return err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+	e.cert, e.leaf, e.modTime = &cert, leaf, e.lastModified()
+	return nil
+}
+
+func (e *certEntry) changed() bool {
+	return !e.lastModified().Equal(e.modTime)
+}
+
+func (e *certEntry) lastModified() time.Time {
+	var latest time.Time
+	for _, file := range []string{e.certFile, e.keyFile} {
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// ClientCAs configures config to require client certificates signed by one of the CAs in caFiles
+func ClientCAs(config *tls.Config, caFiles ...string) error {
+	pool := x509.NewCertPool()
+	for _, file := range caFiles {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("m3lshttp: no certificates found in %s", file)
+		}
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return nil
+}
@@ -0,0 +1,181 @@
+package m3lshttp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+var testSerial int64
+
+func newTestCert(t *testing.T, parent *testCert, commonName string, dnsNames ...string) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	testSerial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(testSerial),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+}
+
+func (c *testCert) write(t *testing.T, dir, name string) (string, string) {
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	require.NoError(t, ioutil.WriteFile(certFile, c.certPEM(), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "m3lshttp")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestCertStoreSNI(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	ca := newTestCert(t, nil, "ca")
+	store := NewCertStore()
+	require.NoError(t, store.AddFile(newTestCert(t, ca, "a", "a.test").write(t, dir, "a")))
+	require.NoError(t, store.AddFile(newTestCert(t, ca, "b", "*.b.test").write(t, dir, "b")))
+
+	for serverName, expected := range map[string]string{"a.test": "a", "x.b.test": "b", "unknown.test": "a", "": "a"} {
+		cert, err := store.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		assert.Equal(t, expected, leaf.Subject.CommonName, serverName)
+	}
+
+	_, err := NewCertStore().GetCertificate(&tls.ClientHelloInfo{})
+	assert.Error(t, err)
+	assert.Error(t, store.AddFile(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key")))
+}
+
+func TestCertStoreReload(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	ca := newTestCert(t, nil, "ca")
+	certFile, keyFile := newTestCert(t, ca, "old", "a.test").write(t, dir, "a")
+	store := NewCertStore()
+	require.NoError(t, store.AddFile(certFile, keyFile))
+
+	commonName := func() string {
+		cert, err := store.GetCertificate(&tls.ClientHelloInfo{ServerName: "a.test"})
+		require.NoError(t, err)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return leaf.Subject.CommonName
+	}
+
+	newTestCert(t, ca, "new", "a.test").write(t, dir, "a")
+	require.NoError(t, store.Reload())
+	assert.Equal(t, "new", commonName())
+
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("garbage"), 0600))
+	assert.Error(t, store.Reload())
+	assert.Equal(t, "new", commonName())
+
+	stop := store.WatchReload(10*time.Millisecond, nil)
+	defer stop()
+	newTestCert(t, ca, "watched", "a.test").write(t, dir, "a")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	assert.Eventually(t, func() bool { return commonName() == "watched" }, time.Second, 10*time.Millisecond)
+}
+
+func TestServeMutualTLS(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	ca := newTestCert(t, nil, "ca")
+	store := NewCertStore()
+	require.NoError(t, store.AddFile(newTestCert(t, ca, "server", "a.test").write(t, dir, "server")))
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, ioutil.WriteFile(caFile, ca.certPEM(), 0600))
+	config := store.TLSConfig()
+	require.NoError(t, ClientCAs(config, caFile))
+
+	handler := NewHttpHandler()
+	peer := make(chan string, 1)
+	handler.GET("/whoami", func(r Request) {
+		assert.NotNil(t, r.TLS())
+		peer <- r.PeerCertificate().Subject.CommonName
+	})
+	ln := fasthttputil.NewInmemoryListener()
+	defer ln.Close()
+	go handler.ServeTLS(ln, config)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	conn, err := ln.Dial()
+	require.NoError(t, err)
+	client := tls.Client(conn, &tls.Config{ServerName: "a.test", RootCAs: roots, Certificates: []tls.Certificate{newTestCert(t, ca, "client-1").tlsCertificate()}})
+	defer client.Close()
+	assert.Equal(t, 200, sendRequest(t, client, "GET", "/whoami", ""))
+	assert.Equal(t, "client-1", <-peer)
+
+	conn, err = ln.Dial()
+	require.NoError(t, err)
+	anonymous := tls.Client(conn, &tls.Config{ServerName: "a.test", RootCAs: roots})
+	defer anonymous.Close()
+	_, err = anonymous.Write([]byte("GET /whoami HTTP/1.1\r\nHost: a.test\r\n\r\n"))
+	if err == nil {
+		_, err = anonymous.Read(make([]byte, 1))
+	}
+	assert.Error(t, err)
+}
+
+func TestPeerCertificateWithoutTLS(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.GET("/", func(r Request) {
+		assert.Nil(t, r.TLS())
+		assert.Nil(t, r.PeerCertificate())
+	})
+	assert.Equal(t, 200, handleRequest(handler, "GET", "/").Response.StatusCode())
+}
@@ -0,0 +1,57 @@
+package m3lshttp
+
+import (
+	"reflect"
+
+	"github.com/mohamed-essam/m3lsh"
+)
+
+type Validator interface {
+	Validate() error
+}
+
+var (
+	requestType = reflect.TypeOf((*Request)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// isTypedHandler matches func(Request, In) (Out, error)
+func isTypedHandler(fn interface{}) bool {
+	t := reflect.TypeOf(fn)
+	return t != nil && t.Kind() == reflect.Func &&
+		t.NumIn() == 2 && t.In(0) == requestType &&
+		t.NumOut() == 2 && t.Out(1) == errorType
+}
+
+func typedHandler(fn interface{}) handler {
+	fnValue := reflect.ValueOf(fn)
+	inType := fnValue.Type().In(1)
+	return func(r Request) {
+		in := reflect.New(inType)
+		decodeInput(r, in.Interface())
+		validateInput(in)
+		out := fnValue.Call([]reflect.Value{reflect.ValueOf(r), in.Elem()})
+		if err, _ := out[1].Interface().(error); err != nil {
+			panic(handlerError{err: err})
+		}
+		Respond(r, Json, out[0].Interface())
+	}
+}
+
+func decodeInput(r Request, dst interface{}) {
+	r.Params().bindInput(dst)
+}
+
+func validateInput(in reflect.Value) {
+	for v := in; v.IsValid(); v = v.Elem() {
+		if validator, ok := v.Interface().(Validator); ok {
+			if err := validator.Validate(); err != nil {
+				m3lsh.Throw(&UnprocessableEntity{}, err.Error())
+			}
+			return
+		}
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package m3lshttp
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (c createUser) Validate() error {
+	if c.Email == "" {
+		return errors.New("email is required")
+	}
+	return nil
+}
+
+func TestIsTypedHandler(t *testing.T) {
+	assert.True(t, isTypedHandler(func(r Request, in createUser) (user, error) { return user{}, nil }))
+	assert.True(t, isTypedHandler(func(r Request, in *createUser) (*user, error) { return nil, nil }))
+	assert.False(t, isTypedHandler(func(r Request, in createUser) user { return user{} }))
+	assert.False(t, isTypedHandler(func(in createUser) (user, error) { return user{}, nil }))
+	assert.False(t, isTypedHandler("not a function"))
+}
+
+func TestTypedHandler(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.POST("/api/users", func(r Request, in createUser) (user, error) {
+		return user{ID: 1, Name: in.Name}, nil
+	})
+	status, body := tempServer(handler.handle, "/api/users", "{\"name\": \"7amada\", \"email\": \"a@b.c\"}", "POST", t)
+	assert.Equal(t, 200, status)
+	var out user
+	require.NoError(t, json.Unmarshal(body, &out))
+	assert.Equal(t, user{ID: 1, Name: "7amada"}, out)
+}
+
+func TestTypedHandlerPointerInput(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.POST("/api/users", func(r Request, in *createUser) (*user, error) {
+		return &user{ID: 2, Name: in.Name}, nil
+	})
+	status, body := tempServer(handler.handle, "/api/users", "{\"name\": \"7amada\", \"email\": \"a@b.c\"}", "POST", t)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "{\"id\":2,\"name\":\"7amada\"}", string(body))
+}
+
+func TestTypedHandlerValidation(t *testing.T) {
+	handler := NewHttpHandler()
+	called := false
+	handler.POST("/api/users", func(r Request, in createUser) (user, error) {
+		called = true
+		return user{}, nil
+	})
+	status, _ := tempServer(handler.handle, "/api/users", "{\"name\": \"7amada\"}", "POST", t)
+	assert.Equal(t, 422, status)
+	assert.False(t, called)
+}
+
+func TestTypedHandlerUndecodableBody(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.POST("/api/users", func(r Request, in createUser) (user, error) {
+		return user{}, nil
+	})
+	status, _ := tempServer(handler.handle, "/api/users", "[1, 2]", "POST", t)
+	assert.Equal(t, 422, status)
+}
+
+func TestTypedHandlerReturnedError(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.POST("/api/users", func(r Request, in createUser) (user, error) {
+		return user{}, &Conflict{}
+	})
+	status, _ := tempServer(handler.handle, "/api/users", "{\"name\": \"7amada\", \"email\": \"a@b.c\"}", "POST", t)
+	assert.Equal(t, 409, status)
+}
+
+func TestTypedHandlerValidatesEmptyBody(t *testing.T) {
+	type signup struct {
+		Name string `json:"name" validate:"required"`
+	}
+	handler := NewHttpHandler()
+	called := false
+	handler.POST("/api/signup", func(r Request, in signup) (signup, error) {
+		called = true
+		return in, nil
+	})
+	status, _ := tempServer(handler.handle, "/api/signup", "", "POST", t)
+	assert.Equal(t, 422, status)
+	assert.False(t, called)
+}
+
+func TestTypedHandlerBindsPathParams(t *testing.T) {
+	type rename struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	handler := NewHttpHandler()
+	handler.POST("/api/users/:id", func(r Request, in rename) (rename, error) {
+		return in, nil
+	}, WithDecodeOptions(DecodeOptions{DisallowUnknownFields: true}))
+	status, body := tempServer(handler.handle, "/api/users/5", "{\"id\": 9, \"name\": \"7amada\"}", "POST", t)
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"id": 5, "name": "7amada"}`, string(body))
+
+	status, body = tempServer(handler.handle, "/api/users/5", "", "POST", t)
+	assert.Equal(t, 200, status)
+	assert.JSONEq(t, `{"id": 5, "name": ""}`, string(body))
+}
@@ -0,0 +1,308 @@
+package m3lshttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mohamed-essam/m3lsh"
+)
+
+type Rule struct {
+	name  string
+	check func(v reflect.Value) string
+}
+
+type Rules map[string][]Rule
+
+type InvalidRuleException struct {
+	m3lsh.BaseException
+}
+
+var (
+	tagRulesLock sync.Mutex
+	tagRules     = make(map[string][]Rule)
+)
+
+func Required() Rule {
+	return Rule{name: "required"}
+}
+
+func Min(min float64) Rule {
+	return Rule{name: "min", check: func(v reflect.Value) string {
+		if size, ok := ruleSize(v); ok && size < min {
+			return fmt.Sprintf("must be at least %v", min)
+		}
+		return ""
+	}}
+}
+
+func Max(max float64) Rule {
+	return Rule{name: "max", check: func(v reflect.Value) string {
+		if size, ok := ruleSize(v); ok && size > max {
+			return fmt.Sprintf("must be at most %v", max)
+		}
+		return ""
+	}}
+}
+
+func Len(length int) Rule {
+	return Rule{name: "len", check: func(v reflect.Value) string {
+		switch v.Kind() {
+		case reflect.String:
+			if len([]rune(v.String())) != length {
+				return fmt.Sprintf("must have length %d", length)
+			}
+		case reflect.Slice, reflect.Array, reflect.Map:
+			if v.Len() != length {
+				return fmt.Sprintf("must have length %d", length)
+			}
+		}
+		return ""
+	}}
+}
+
+func Regex(pattern string) Rule {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		m3lsh.Throw(&InvalidRuleException{}, fmt.Sprintf("Invalid regex %q: %s", pattern, err))
+	}
+	return Rule{name: "regex", check: func(v reflect.Value) string {
+		if v.Kind() == reflect.String && !re.MatchString(v.String()) {
+			return fmt.Sprintf("must match %s", pattern)
+		}
+		return ""
+	}}
+}
+
+func Enum(values ...string) Rule {
+	return Rule{name: "enum", check: func(v reflect.Value) string {
+		str := fmt.Sprint(v.Interface())
+		for _, value := range values {
+			if str == value {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(values, ", "))
+	}}
+}
+
+func Email() Rule {
+	return Rule{name: "email", check: func(v reflect.Value) string {
+		if v.Kind() != reflect.String {
+			return "must be an email address"
+		}
+		address, err := mail.ParseAddress(v.String())
+		if err != nil || address.Address != v.String() {
+			return "must be an email address"
+		}
+		return ""
+	}}
+}
+
+func Url() Rule {
+	return Rule{name: "url", check: func(v reflect.Value) string {
+		if v.Kind() != reflect.String {
+			return "must be a URL"
+		}
+		u, err := url.ParseRequestURI(v.String())
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a URL"
+		}
+		return ""
+	}}
+}
+
+// ruleSize is the number compared by min and max: the value itself for numbers, the length otherwise
+func ruleSize(v reflect.Value) (float64, bool) {
+	if number, ok := v.Interface().(json.Number); ok {
+		num, err := number.Float64()
+		return num, err == nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(len([]rune(v.String()))), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
+
+// parseRules reads a validate tag, regex takes the rest of the tag so its pattern may contain commas
+func parseRules(tag string) []Rule {
+	tagRulesLock.Lock()
+	defer tagRulesLock.Unlock()
+	if rules, ok := tagRules[tag]; ok {
+		return rules
+	}
+	rules := make([]Rule, 0)
+	rest := tag
+	for rest != "" {
+		var part string
+		if strings.HasPrefix(rest, "regex=") {
+			part, rest = rest, ""
+		} else if idx := strings.Index(rest, ","); idx >= 0 {
+			part, rest = rest[:idx], rest[idx+1:]
+		} else {
+			part, rest = rest, ""
+		}
+		if part != "" {
+			rules = append(rules, parseRule(part))
+		}
+	}
+	tagRules[tag] = rules
+	return rules
+}
+
+func parseRule(part string) Rule {
+	kv := strings.SplitN(part, "=", 2)
+	name, arg := kv[0], ""
+	if len(kv) == 2 {
+		arg = kv[1]
+	}
+	switch name {
+	case "required":
+		return Required()
+	case "min", "max":
+		num, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			m3lsh.Throw(&InvalidRuleException{}, fmt.Sprintf("Invalid %s rule %q", name, part))
+		}
+		if name == "min" {
+			return Min(num)
+		}
+		return Max(num)
+	case "len":
+		num, err := strconv.Atoi(arg)
+		if err != nil {
+			m3lsh.Throw(&InvalidRuleException{}, fmt.Sprintf("Invalid len rule %q", part))
+		}
+		return Len(num)
+	case "regex":
+		return Regex(arg)
+	case "enum":
+		return Enum(strings.Split(arg, "|")...)
+	case "email":
+		return Email()
+	case "url":
+		return Url()
+	}
+	m3lsh.Throw(&InvalidRuleException{}, fmt.Sprintf("Unknown validation rule %q", part))
+	return Rule{}
+}
+
+func applyRules(rules []Rule, v reflect.Value, present bool, path string, errors []FieldError) []FieldError {
+	// only an absent key or a nil pointer skips the rules, a zero value is still checked;
+	// required also rejects zero values, a set pointer is checked by the value it points to
+	missing := !present || !v.IsValid() || isNilValue(v)
+	empty := missing || isEmptyValue(v)
+	for !missing && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+		missing = !v.IsValid() || isNilValue(v)
+	}
+	for _, rule := range rules {
+		if rule.name == "required" {
+			if empty {
+				errors = append(errors, FieldError{Field: path, Message: "is required"})
+				return errors
+			}
+			continue
+		}
+		if missing {
+			continue
+		}
+		if message := rule.check(v); message != "" {
+			errors = append(errors, FieldError{Field: path, Message: message})
+		}
+	}
+	return errors
+}
+
+func isNilValue(v reflect.Value) bool {
+	return (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil()
+}
+
+// Validate checks the validate tags of v; every rule except required skips only nil pointers,
+// so optional fields whose zero value should not be checked have to be pointers
+func Validate(v interface{}) {
+	if errors := validateValue(reflect.ValueOf(v), "", make([]FieldError, 0)); len(errors) > 0 {
+		throwFieldErrors("Validation failed", errors)
+	}
+}
+
+func validateValue(v reflect.Value, path string, errors []FieldError) []FieldError {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return errors
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, tagged, skip := bindFieldName(field)
+			if skip {
+				continue
+			}
+			if field.Anonymous && !tagged && field.Type.Kind() == reflect.Struct {
+				errors = validateValue(v.Field(i), path, errors)
+				continue
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+			fieldPath := joinPath(path, name)
+			if tag := field.Tag.Get("validate"); tag != "" {
+				errors = applyRules(parseRules(tag), v.Field(i), true, fieldPath, errors)
+			}
+			errors = validateValue(v.Field(i), fieldPath, errors)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			errors = validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errors)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return errors
+		}
+		for _, k := range v.MapKeys() {
+			errors = validateValue(v.MapIndex(k), joinPath(path, k.String()), errors)
+		}
+	}
+	return errors
+}
+
+// rule keys are paths as accepted by Get
+func (p ParamsWrapper) Validate(rules Rules) {
+	if _, ok := p.object.(map[string]interface{}); !ok {
+		m3lsh.Throw(&InvalidTypeException{Object: p.object}, "Object is not a map")
+	}
+	keys := make([]string, 0, len(rules))
+	for key := range rules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	errors := make([]FieldError, 0)
+	for _, key := range keys {
+		value, failed := resolvePath(p.object, parsePath(key))
+		errors = applyRules(rules[key], reflect.ValueOf(value), failed == "", key, errors)
+	}
+	if len(errors) > 0 {
+		throwFieldErrors("Validation failed", errors)
+	}
+}
@@ -0,0 +1,175 @@
+package m3lshttp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validatedItem struct {
+	SKU string `json:"sku" validate:"required,len=4"`
+}
+
+type validatedOrder struct {
+	Email    string          `json:"email" validate:"required,email"`
+	Quantity int             `json:"quantity" validate:"min=1,max=10"`
+	Status   string          `json:"status" validate:"enum=new|paid"`
+	Callback string          `json:"callback" validate:"url"`
+	Code     string          `json:"code" validate:"regex=^[A-Z]{2,3}$"`
+	Items    []validatedItem `json:"items" validate:"required,min=1"`
+	Note     string          `json:"note" validate:"max=5"`
+}
+
+func fieldErrors(t *testing.T, fn func()) map[string]string {
+	ex := m3lsh.Try(fn)
+	require.IsType(t, &UnprocessableEntity{}, ex)
+	ret := make(map[string]string)
+	for _, e := range ex.(*UnprocessableEntity).Fields["errors"].([]FieldError) {
+		ret[e.Field] = e.Message
+	}
+	return ret
+}
+
+func TestValidateValid(t *testing.T) {
+	order := validatedOrder{
+		Email:    "a@b.co",
+		Quantity: 2,
+		Status:   "paid",
+		Callback: "https://example.com/hook",
+		Code:     "EG",
+		Items:    []validatedItem{{SKU: "AB12"}},
+	}
+	assert.Nil(t, m3lsh.Try(func() { Validate(&order) }))
+}
+
+func TestValidateCollectsAllErrors(t *testing.T) {
+	order := validatedOrder{
+		Email:    "not-an-email",
+		Quantity: 11,
+		Status:   "lost",
+		Callback: "/relative",
+		Code:     "egypt",
+		Items:    []validatedItem{{SKU: "A"}, {}},
+		Note:     "too long",
+	}
+	errors := fieldErrors(t, func() { Validate(order) })
+	assert.Equal(t, map[string]string{
+		"email":        "must be an email address",
+		"quantity":     "must be at most 10",
+		"status":       "must be one of new, paid",
+		"callback":     "must be a URL",
+		"code":         "must match ^[A-Z]{2,3}$",
+		"items[0].sku": "must have length 4",
+		"items[1].sku": "is required",
+		"note":         "must be at most 5",
+	}, errors)
+}
+
+func TestValidateChecksZeroValues(t *testing.T) {
+	errors := fieldErrors(t, func() { Validate(validatedOrder{}) })
+	assert.Equal(t, map[string]string{
+		"email":    "is required",
+		"quantity": "must be at least 1",
+		"status":   "must be one of new, paid",
+		"callback": "must be a URL",
+		"code":     "must match ^[A-Z]{2,3}$",
+		"items":    "is required",
+	}, errors)
+}
+
+func TestBindChecksZeroValues(t *testing.T) {
+	type line struct {
+		Quantity int    `json:"quantity" validate:"min=1"`
+		Label    string `json:"label" validate:"min=3"`
+	}
+	var dst line
+	errors := fieldErrors(t, func() {
+		newParams(map[string]interface{}{"quantity": json.Number("0"), "label": ""}).GetObject("_data").Bind(&dst)
+	})
+	assert.Equal(t, map[string]string{"quantity": "must be at least 1", "label": "must be at least 3"}, errors)
+}
+
+func TestBindValidates(t *testing.T) {
+	p := newParams(map[string]interface{}{
+		"email":    "a@b.co",
+		"quantity": "abc",
+		"status":   "new",
+		"callback": "https://example.com/hook",
+		"code":     "EG",
+		"items":    []interface{}{},
+	})
+	var order validatedOrder
+	errors := fieldErrors(t, func() { p.GetObject("_data").Bind(&order) })
+	assert.Equal(t, map[string]string{
+		"quantity": "String cannot be converted to int64",
+		"items":    "is required",
+	}, errors)
+}
+
+func TestParamsValidateRules(t *testing.T) {
+	p := newParams(map[string]interface{}{"name": "7amada", "age": float64(5), "role": "owner"})
+	errors := fieldErrors(t, func() {
+		p.GetObject("_data").Validate(Rules{
+			"name":  {Required(), Len(6)},
+			"age":   {Min(18)},
+			"role":  {Enum("admin", "member")},
+			"email": {Required(), Email()},
+		})
+	})
+	assert.Equal(t, map[string]string{
+		"age":   "must be at least 18",
+		"role":  "must be one of admin, member",
+		"email": "is required",
+	}, errors)
+}
+
+func TestParamsValidateZeroValues(t *testing.T) {
+	p := newParams(map[string]interface{}{"q": float64(0), "name": "", "note": nil}).GetObject("_data")
+	errors := fieldErrors(t, func() {
+		p.Validate(Rules{
+			"q":       {Min(1)},
+			"name":    {Len(3)},
+			"note":    {Len(3)},
+			"missing": {Min(1)},
+		})
+	})
+	assert.Equal(t, map[string]string{"q": "must be at least 1", "name": "must have length 3"}, errors)
+}
+
+func TestParseRulesUnknown(t *testing.T) {
+	ex := m3lsh.Try(func() {
+		parseRules("required,shiny")
+		t.Error("Unknown rule not reported")
+	})
+	assert.IsType(t, &InvalidRuleException{}, ex)
+}
+
+func TestTypedHandlerValidatesTags(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.POST("/api/orders", func(r Request, in validatedOrder) (validatedOrder, error) {
+		return in, nil
+	})
+	status, _ := tempServer(handler.handle, "/api/orders", "{\"email\": \"bad\"}", "POST", t)
+	assert.Equal(t, 422, status)
+}
+
+func TestValidateOptionalPointers(t *testing.T) {
+	type profile struct {
+		Email *string `json:"email" validate:"email"`
+		Age   *int    `json:"age" validate:"min=18"`
+		Nick  *string `json:"nick" validate:"required"`
+	}
+	email, age, nick := "a@b.com", 21, ""
+	Validate(&profile{Email: &email, Age: &age, Nick: &nick})
+
+	badEmail, young := "nope", 5
+	errors := fieldErrors(t, func() { Validate(&profile{Email: &badEmail, Age: &young}) })
+	assert.Equal(t, map[string]string{
+		"email": "must be an email address",
+		"age":   "must be at least 18",
+		"nick":  "is required",
+	}, errors)
+}
@@ -0,0 +1,297 @@
+package m3lshttp
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mohamed-essam/m3lsh"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	continuationFrame = 0
+	TextMessage       = 1
+	BinaryMessage     = 2
+	CloseMessage      = 8
+	PingMessage       = 9
+	PongMessage       = 10
+)
+
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var WebSocketReadLimit int64 = 1 << 20
+
+var errWebSocketClosed = errors.New("websocket connection closed")
+
+type WebSocketCloseError struct {
+	Code   int
+	Reason string
+}
+
+type WebSocketConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	params    Params
+	readLimit int64
+	writeLock sync.Mutex
+	closeSent bool
+}
+
+func (e *WebSocketCloseError) Error() string {
+	return fmt.Sprintf("websocket closed with code %d: %s", e.Code, e.Reason)
+}
+
+func (h *HttpHandler) WebSocket(path string, fn func(c *WebSocketConn)) {
+	h.GET(path, func(r Request) {
+		ctx := r.context()
+		key := string(ctx.Request.Header.Peek("Sec-WebSocket-Key"))
+		if !headerHasToken(ctx.Request.Header.Peek("Connection"), "upgrade") ||
+			!headerHasToken(ctx.Request.Header.Peek("Upgrade"), "websocket") {
+			m3lsh.Throw(&BadRequest{}, "Not a websocket handshake")
+		}
+		if string(ctx.Request.Header.Peek("Sec-WebSocket-Version")) != "13" {
+			m3lsh.Throw(&BadRequest{}, "Unsupported websocket version")
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+			m3lsh.Throw(&BadRequest{}, "Invalid Sec-WebSocket-Key")
+		}
+		params := r.Params()
+		ctx.SetStatusCode(fasthttp.StatusSwitchingProtocols)
+		ctx.Response.Header.Set("Upgrade", "websocket")
+		ctx.Response.Header.Set("Connection", "Upgrade")
+		ctx.Response.Header.Set("Sec-WebSocket-Accept", webSocketAccept(key))
+		ctx.Hijack(func(c net.Conn) {
+			conn := newWebSocketConn(c, params)
+			// the hijack handler runs on its own goroutine, outside recoverPanic
+			defer func() {
+				code := CloseNormal
+				if e := recover(); e != nil {
+					code = CloseInternalError
+					r.reportError(&PanicError{Value: e, Stack: debug.Stack()})
+				}
+				conn.Close(code, "")
+			}()
+			fn(conn)
+		})
+	})
+}
+
+func newWebSocketConn(c net.Conn, params Params) *WebSocketConn {
+	return &WebSocketConn{conn: c, reader: bufio.NewReader(c), params: params, readLimit: WebSocketReadLimit}
+}
+
+func webSocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerHasToken(value []byte, token string) bool {
+	for _, part := range strings.Split(string(value), ",") {
+		if strings.EqualFold(strings.TrimSpace(part), token) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *WebSocketConn) Params() Params {
+	return c.params
+}
+
+func (c *WebSocketConn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+func (c *WebSocketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *WebSocketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// ReadMessage answers pings and reassembles fragments until a whole text or binary message arrives
+func (c *WebSocketConn) ReadMessage() (int, []byte, error) {
+	messageType := 0
+	message := make([]byte, 0)
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, c.fail(err)
+		}
+		switch opcode {
+		case PingMessage:
+			if err := c.writeFrame(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			closeErr := &WebSocketCloseError{Code: 1005}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			if closeErr.Code == 1005 {
+				c.Close(CloseNormal, "")
+			} else {
+				c.Close(closeErr.Code, "")
+			}
+			return 0, nil, closeErr
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(&WebSocketCloseError{Code: CloseProtocolError, Reason: "unexpected continuation frame"})
+			}
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(&WebSocketCloseError{Code: CloseProtocolError, Reason: "expected continuation frame"})
+			}
+			messageType = opcode
+		default:
+			return 0, nil, c.fail(&WebSocketCloseError{Code: CloseProtocolError, Reason: "unknown opcode"})
+		}
+		if int64(len(message)+len(payload)) > c.readLimit {
+			return 0, nil, c.fail(&WebSocketCloseError{Code: CloseMessageTooBig, Reason: "message too big"})
+		}
+		message = append(message, payload...)
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(message) {
+				return 0, nil, c.fail(&WebSocketCloseError{Code: CloseInvalidPayload, Reason: "invalid utf-8"})
+			}
+			return messageType, message, nil
+		}
+	}
+}
+
+func (c *WebSocketConn) readFrame() (bool, int, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := int(header[0] & 0x0f)
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, &WebSocketCloseError{Code: CloseProtocolError, Reason: "reserved bits set"}
+	}
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, &WebSocketCloseError{Code: CloseProtocolError, Reason: "client frames must be masked"}
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+	if opcode >= CloseMessage && (length > 125 || !fin) {
+		return false, 0, nil, &WebSocketCloseError{Code: CloseProtocolError, Reason: "invalid control frame"}
+	}
+	if length > uint64(c.readLimit) {
+		return false, 0, nil, &WebSocketCloseError{Code: CloseMessageTooBig, Reason: "message too big"}
+	}
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(c.reader, mask); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+func (c *WebSocketConn) fail(err error) error {
+	if closeErr, ok := err.(*WebSocketCloseError); ok {
+		c.Close(closeErr.Code, closeErr.Reason)
+	} else {
+		c.conn.Close()
+	}
+	return err
+}
+
+func (c *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", messageType)
+	}
+	return c.writeFrame(messageType, data)
+}
+
+func (c *WebSocketConn) WriteText(text string) error {
+	return c.writeFrame(TextMessage, []byte(text))
+}
+
+func (c *WebSocketConn) Ping(data []byte) error {
+	return c.writeFrame(PingMessage, data)
+}
+
+func (c *WebSocketConn) Close(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	err := c.writeFrame(CloseMessage, payload)
+	c.conn.Close()
+	if err == errWebSocketClosed {
+		return nil
+	}
+	return err
+}
+
+func (c *WebSocketConn) writeFrame(opcode int, payload []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if c.closeSent {
+		return errWebSocketClosed
+	}
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|byte(opcode))
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, 126, byte(len(payload)>>8), byte(len(payload)))
+	default:
+		ext := make([]byte, 8)
+		binary.BigEndian.PutUint64(ext, uint64(len(payload)))
+		frame = append(append(frame, 127), ext...)
+	}
+	frame = append(frame, payload...)
+	_, err := c.conn.Write(frame)
+	return err
+}
@@ -0,0 +1,168 @@
+package m3lshttp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func webSocketServer(handler *HttpHandler, path string, t *testing.T) (net.Conn, *bufio.Reader, func()) {
+	s := &fasthttp.Server{Handler: handler.handle}
+	ln := fasthttputil.NewInmemoryListener()
+	go s.Serve(ln)
+
+	c, err := ln.Dial()
+	require.NoError(t, err)
+	req := "GET " + path + " HTTP/1.1\r\nHost: 7amada\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	_, err = c.Write([]byte(req))
+	require.NoError(t, err)
+
+	br := bufio.NewReader(c)
+	var resp fasthttp.Response
+	resp.SkipBody = true
+	require.NoError(t, resp.Read(br))
+	assert.Equal(t, 101, resp.StatusCode())
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", string(resp.Header.Peek("Sec-WebSocket-Accept")))
+	return c, br, func() { c.Close(); ln.Close() }
+}
+
+func writeClientFrame(c net.Conn, fin bool, opcode byte, payload []byte) {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, 0x80|byte(len(payload)))
+	default:
+		frame = append(frame, 0x80|126, byte(len(payload)>>8), byte(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	c.Write(frame)
+}
+
+func readServerFrame(t *testing.T, br *bufio.Reader) (byte, []byte) {
+	header := make([]byte, 2)
+	_, err := io.ReadFull(br, header)
+	require.NoError(t, err)
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		ext := make([]byte, 2)
+		io.ReadFull(br, ext)
+		length = int(binary.BigEndian.Uint16(ext))
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(br, payload)
+	require.NoError(t, err)
+	return header[0] & 0x0f, payload
+}
+
+func TestWebSocketAccept(t *testing.T) {
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", webSocketAccept("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.WebSocket("/ws/rooms/:room", func(c *WebSocketConn) {
+		room := c.Params().GetObject("room").StringValue()
+		for {
+			messageType, data, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			c.WriteMessage(messageType, append([]byte(room+":"), data...))
+		}
+	})
+	c, br, done := webSocketServer(handler, "/ws/rooms/lobby", t)
+	defer done()
+	c.SetDeadline(time.Now().Add(2 * time.Second))
+
+	writeClientFrame(c, true, TextMessage, []byte("hello"))
+	opcode, payload := readServerFrame(t, br)
+	assert.Equal(t, byte(TextMessage), opcode)
+	assert.Equal(t, "lobby:hello", string(payload))
+
+	writeClientFrame(c, false, BinaryMessage, []byte{1})
+	writeClientFrame(c, true, PingMessage, []byte("p"))
+	writeClientFrame(c, true, continuationFrame, []byte{2})
+	opcode, payload = readServerFrame(t, br)
+	assert.Equal(t, byte(PongMessage), opcode)
+	assert.Equal(t, "p", string(payload))
+	opcode, payload = readServerFrame(t, br)
+	assert.Equal(t, byte(BinaryMessage), opcode)
+	assert.Equal(t, append([]byte("lobby:"), 1, 2), payload)
+
+	writeClientFrame(c, true, CloseMessage, []byte{0x03, 0xe8})
+	opcode, payload = readServerFrame(t, br)
+	assert.Equal(t, byte(CloseMessage), opcode)
+	assert.Equal(t, []byte{0x03, 0xe8}, payload)
+}
+
+func TestWebSocketMessageTooBig(t *testing.T) {
+	handler := NewHttpHandler()
+	var readErr error
+	finished := make(chan struct{})
+	handler.WebSocket("/ws", func(c *WebSocketConn) {
+		c.SetReadLimit(4)
+		_, _, readErr = c.ReadMessage()
+		close(finished)
+	})
+	c, br, done := webSocketServer(handler, "/ws", t)
+	defer done()
+	c.SetDeadline(time.Now().Add(2 * time.Second))
+
+	writeClientFrame(c, true, TextMessage, []byte("too long"))
+	opcode, payload := readServerFrame(t, br)
+	assert.Equal(t, byte(CloseMessage), opcode)
+	assert.Equal(t, CloseMessageTooBig, int(binary.BigEndian.Uint16(payload)))
+	<-finished
+	require.IsType(t, &WebSocketCloseError{}, readErr)
+	assert.Equal(t, CloseMessageTooBig, readErr.(*WebSocketCloseError).Code)
+}
+
+func TestWebSocketRejectsPlainRequest(t *testing.T) {
+	handler := NewHttpHandler()
+	handler.WebSocket("/ws", func(c *WebSocketConn) {})
+	status, _ := tempServer(func(ctx *fasthttp.RequestCtx) {
+		handler.handle(ctx)
+	}, "/ws", "", "GET", t)
+	assert.Equal(t, 400, status)
+}
+
+func TestWebSocketPanic(t *testing.T) {
+	handler := NewHttpHandler()
+	reported := make(chan error, 1)
+	handler.OnError(func(r Request, err error) {
+		reported <- err
+	})
+	handler.WebSocket("/ws/rooms/:room", func(c *WebSocketConn) {
+		c.Params().GetObject("room").Integer()
+	})
+	c, br, done := webSocketServer(handler, "/ws/rooms/lobby", t)
+	defer done()
+	c.SetDeadline(time.Now().Add(2 * time.Second))
+
+	opcode, payload := readServerFrame(t, br)
+	assert.Equal(t, byte(CloseMessage), opcode)
+	assert.Equal(t, CloseInternalError, int(binary.BigEndian.Uint16(payload)))
+	select {
+	case err := <-reported:
+		assert.IsType(t, &InvalidTypeException{}, err.(*PanicError).Value)
+	case <-time.After(time.Second):
+		t.Error("WebSocket panic not reported")
+	}
+}